@@ -1,8 +1,6 @@
 package main
 
 import (
-	"bufio"
-	"encoding/base64"
 	"fmt"
 	"os"
 	"strings"
@@ -18,17 +16,44 @@ import (
  */
 func status() {
 	if _statusFileExists() {
-		task, startTimeString := _readStatusFile()
-		startTime, err := time.Parse(time.RFC3339, startTimeString)
+		status, err := _readStatusFile()
 		check(err)
 
-		formattedDiff := _formatDuration(time.Since(startTime))
+		formattedDiff := _formatDuration(_netDuration(status, time.Now()))
 
-		fmt.Println("Task", task, "started", formattedDiff, "ago.", startTime.Format(time.ANSIC))
+		if _isPaused(status) {
+			fmt.Println("Task", status.Task, "paused with", formattedDiff, "tracked.", status.Start.Format(time.ANSIC))
+		} else if len(status.Pauses) > 0 {
+			fmt.Println("Task", status.Task, "resumed with", formattedDiff, "tracked.", status.Start.Format(time.ANSIC))
+		} else {
+			fmt.Println("Task", status.Task, "started", formattedDiff, "ago.", status.Start.Format(time.ANSIC))
+		}
+
+		if index := _profileIndex(status.Profile); index != -1 {
+			fmt.Println(fmt.Sprintf("Upstream profile %s (%s).", status.Profile, config.Profiles[index].Service))
+		}
+
+		if cached, found := _readIssueCache(status.Task); found {
+			elapsed := _netDuration(status, time.Now())
+
+			fmt.Println(_issueSummary(cached, elapsed, 0), "-", cached.Issue.State)
+
+			if over := _overEstimateSeconds(cached, elapsed); over > 0 {
+				fmt.Println(fmt.Sprintf("Warning: %s is %s over its estimate.", status.Task, _formatHours(over)))
+			}
+		}
 
 	} else {
 		fmt.Println("No task currently started")
 	}
+
+	if pending := _pendingSyncCount(); pending > 0 {
+		fmt.Println(fmt.Sprintf("%d worklogs pending upstream, run `timer sync` to retry.", pending))
+	}
+
+	if webhooks, err := _readWebhookQueue(); err == nil && len(webhooks) > 0 {
+		fmt.Println(fmt.Sprintf("%d webhook events pending, run `timer sync` to retry.", len(webhooks)))
+	}
 	os.Exit(0)
 }
 
@@ -38,25 +63,13 @@ func status() {
  */
 func start(task, atTime string) {
 	if _statusFileExists() {
-		fmt.Println("Error: A task is already started.")
-		os.Exit(1)
+		check(&UsageError{Message: "a task is already started"})
 	} else {
-		now := time.Now()
-		var startTime time.Time
-
-		if atTime != "" {
-			loc, err := time.LoadLocation("Local")
-			check(err)
-			startTime, err = time.ParseInLocation("2006-01-02T15:04:05", fmt.Sprintf("%d-%02d-%02dT%s:00", now.Year(), int(now.Month()), now.Day(), atTime), loc)
-			check(err)
-
-		} else {
-			startTime = now
-		}
+		startTime, err := _parseAtTime(atTime)
+		check(err)
 
-		if now.Before(startTime) {
-			fmt.Println("Error, cannot start task in the future.")
-			os.Exit(1)
+		if time.Now().Before(startTime) {
+			check(&UsageError{Message: "cannot start task in the future"})
 		}
 
 		if task == "" {
@@ -64,137 +77,377 @@ func start(task, atTime string) {
 			os.Exit(1)
 		}
 
-		_writeStatusFile(fmt.Sprintf("%s,%s", task, startTime.Format(time.RFC3339)))
+		check(_validateTaskName(task))
+
+		task, profile := _routeTaskHere(task)
+		status := TimerStatus{Task: task, Start: startTime, Profile: profile}
+		cached, resolved := _resolveStartIssue(task, profile)
+
+		check(_writeStatusFile(status))
 		fmt.Println(fmt.Sprintf("Started %s at %s", task, startTime.Format(time.Kitchen)))
+
+		if resolved {
+			check(_writeIssueCache(cached))
+			fmt.Println(_issueSummary(cached, 0, 0))
+		}
+
+		_sendWebhook(_startWebhookEvent(status))
 	}
 }
 
+/**
+ * Pause
+ * Pause the running task timer, paused time is not counted when stopping.
+ */
+func pause(atTime string) {
+	if _statusFileExists() {
+		status, err := _readStatusFile()
+		check(err)
+		pauseTime, err := _parseAtTime(atTime)
+		check(err)
+
+		if _isPaused(status) {
+			check(&UsageError{Message: "task " + status.Task + " is already paused"})
+		}
+
+		if time.Now().Before(pauseTime) {
+			check(&UsageError{Message: "cannot pause task in the future"})
+		}
+
+		if pauseTime.Before(status.Start) || (len(status.Pauses) > 0 && pauseTime.Before(status.Pauses[len(status.Pauses)-1].End)) {
+			check(&UsageError{Message: "cannot pause task before it was last started or resumed"})
+		}
+
+		status.Pauses = append(status.Pauses, PauseInterval{Start: pauseTime})
+		check(_writeStatusFile(status))
+
+		fmt.Println(fmt.Sprintf("Paused %s at %s, %s tracked.", status.Task, pauseTime.Format(time.Kitchen), _formatDuration(_netDuration(status, pauseTime))))
+	} else {
+		fmt.Println("No task started.")
+	}
+	os.Exit(0)
+}
+
+/**
+ * Resume
+ * Resume a paused task timer.
+ */
+func resume(atTime string) {
+	if _statusFileExists() {
+		status, err := _readStatusFile()
+		check(err)
+		resumeTime, err := _parseAtTime(atTime)
+		check(err)
+
+		if !_isPaused(status) {
+			check(&UsageError{Message: "task " + status.Task + " is not paused"})
+		}
+
+		if time.Now().Before(resumeTime) {
+			check(&UsageError{Message: "cannot resume task in the future"})
+		}
+
+		lastPause := &status.Pauses[len(status.Pauses)-1]
+
+		if resumeTime.Before(lastPause.Start) {
+			check(&UsageError{Message: "cannot resume task before it was paused"})
+		}
+
+		lastPause.End = resumeTime
+		check(_writeStatusFile(status))
+
+		fmt.Println(fmt.Sprintf("Resumed %s at %s", status.Task, resumeTime.Format(time.Kitchen)))
+	} else {
+		fmt.Println("No task started.")
+	}
+	os.Exit(0)
+}
+
 /**
  * Stop
  * Stop a task timer and commit the time elapsed to the log file.
  */
-func stop(atTime string) {
+func stop(atTime string, preset TaskDescription, noPrompt bool) {
 	if _statusFileExists() {
-		now := time.Now()
-		var endTime time.Time
-
-		if atTime != "" {
-			loc, err := time.LoadLocation("Local")
-			check(err)
-			endTime, err = time.ParseInLocation("2006-01-02T15:04:05", fmt.Sprintf("%d-%02d-%02dT%s:00", now.Year(), int(now.Month()), now.Day(), atTime), loc)
-			check(err)
+		endTime, err := _parseAtTime(atTime)
+		check(err)
+		status, err := _readStatusFile()
+		check(err)
 
-		} else {
-			endTime = now
+		if endTime.Before(status.Start) {
+			check(&UsageError{Message: "cannot stop task before it was started"})
 		}
 
-		task, startTimeString := _readStatusFile()
-		startTime, err := time.Parse(time.RFC3339, startTimeString)
+		_stopTask(status, endTime, preset, noPrompt)
+
+	} else {
+		fmt.Println("No task started.")
+	}
+	os.Exit(0)
+}
+
+/**
+ * Switch
+ * Stop the current task and start another at the same moment so the log has no gap or overlap.
+ */
+func switchTask(task, atTime string, preset TaskDescription, noPrompt bool) {
+	if task == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	check(_validateTaskName(task))
+
+	switchTime, err := _parseAtTime(atTime)
+	check(err)
+
+	if time.Now().Before(switchTime) {
+		check(&UsageError{Message: "cannot switch task in the future"})
+	}
+
+	// the new task is checked before the current one is stopped
+	task, profile := _routeTaskHere(task)
+	cached, resolved := _resolveStartIssue(task, profile)
+
+	if _statusFileExists() {
+		status, err := _readStatusFile()
 		check(err)
 
-		formattedDuration := _formatDuration(endTime.Sub(startTime))
-		day := startTime.Format("2006-01-02")
+		if switchTime.Before(status.Start) {
+			check(&UsageError{Message: "cannot switch task before the current task was started"})
+		}
+
+		_stopTask(status, switchTime, preset, noPrompt)
+	}
+
+	status := TimerStatus{Task: task, Start: switchTime, Profile: profile}
+
+	check(_writeStatusFile(status))
+	fmt.Println(fmt.Sprintf("Started %s at %s", task, switchTime.Format(time.Kitchen)))
+
+	if resolved {
+		check(_writeIssueCache(cached))
+		fmt.Println(_issueSummary(cached, 0, 0))
+	}
+
+	_sendWebhook(_startWebhookEvent(status))
+	os.Exit(0)
+}
+
+// log the task ending at endTime, remove the status file and push the worklog upstream.
+func _stopTask(status TimerStatus, endTime time.Time, preset TaskDescription, noPrompt bool) {
+	task := status.Task
+	startTime := status.Start
+
+	// time spent paused is excluded from the logged and submitted duration
+	netDuration := _netDuration(status, endTime)
+	formattedDuration := _formatDuration(netDuration)
+
+	fmt.Println(fmt.Sprintf("Stopping %s...", task))
+
+	taskInfo := _askTaskDescription(task, preset, noPrompt)
+
+	entry := _newLogEntry(task, startTime, endTime, int64(netDuration.Round(time.Second)/time.Second), taskInfo)
+	entry.Profile = status.Profile
+	check(_appendLogEntry(entry))
+
+	check(_removeStatusFile())
+
+	fmt.Println(fmt.Sprintf("Stopped %s %s elapsed.", task, formattedDuration))
+
+	entry = _syncLogEntry(entry, taskInfo)
 
-		if _logFileExists(day) == false {
-			_createLogFile(day)
+	_sendWebhook(_stopWebhookEvent(entry))
+}
+
+// the status file is comma separated, so running tasks can't contain a comma.
+func _validateTaskName(task string) error {
+	if strings.Contains(task, ",") {
+		return &UsageError{Message: fmt.Sprintf("task %q can't contain a comma", task)}
+	}
+
+	return nil
+}
+
+var billableStatuses = []string{"Billable", "Not Billable"}
+
+// ask for the task description fields not already given by flags, without prompting when noPrompt is set or stdin is not a terminal.
+func _askTaskDescription(task string, preset TaskDescription, noPrompt bool) TaskDescription {
+	taskInfo := preset
+
+	if preset.Status != "" && !_contains(billableStatuses, preset.Status) {
+		check(&UsageError{Message: fmt.Sprintf("unknown status %q, expected one of %v", preset.Status, billableStatuses)})
+	}
+
+	if noPrompt || !_isTerminal(os.Stdin) {
+		if taskInfo.JobType == "" {
+			taskInfo.JobType = config.default_job_type
 		}
 
-		fmt.Println(fmt.Sprintf("Stopping %s...", task))
-
-		// @TODO dynamic survey options, allow entry of new value
-		var taskInfo TaskDescription
-		var taskSurvey = []*survey.Question{
-			{
-				Name: "JobType",
-				Prompt: &survey.Select{
-					Message: "JobType:",
-					Options: []string{
-						"Frontend Development",
-						"Code Review",
-						"Deployment",
-						"Internal Meeting",
-						"Backend Development",
-						"Design",
-						"Client Meeting",
-						"Quality Assurance",
-						"Project Discovery",
-						"Project Management",
-						"Strategy",
-						"Site Analysis",
-						"Research",
-					},
-					Default: "Frontend Development",
-				},
-			},
+		if taskInfo.JobType == "" {
+			check(&UsageError{Message: "no job type given, use -type or set default_job_type in the config when not prompting"})
 		}
 
-		if config.billable_enable {
-			taskSurvey = append(taskSurvey, &survey.Question{
-				Name: "Status",
-				Prompt: &survey.Select{
-					Message: "Status:",
-					Options: []string{"Billable", "Not Billable"},
-					Default: "Billable",
-				},
-			})
+		if config.billable_enable && taskInfo.Status == "" {
+			taskInfo.Status = config.default_status
 		}
 
+		if config.billable_enable && taskInfo.Status == "" {
+			check(&UsageError{Message: "no billable status given, use -status or set default_status in the config when not prompting"})
+		}
+
+		return taskInfo
+	}
+
+	var taskSurvey []*survey.Question
+
+	if preset.JobType == "" {
+		jobTypeOptions, jobTypeDefault := _jobTypeOptions(task, "")
+
+		taskSurvey = append(taskSurvey, &survey.Question{
+			Name: "JobType",
+			Prompt: &survey.Select{
+				Message: "JobType:",
+				Options: jobTypeOptions,
+				Default: jobTypeDefault,
+			},
+		})
+	}
+
+	if config.billable_enable && preset.Status == "" {
+		statusDefault := config.default_status
+		if !_contains(billableStatuses, statusDefault) {
+			statusDefault = "Billable"
+		}
+
+		taskSurvey = append(taskSurvey, &survey.Question{
+			Name: "Status",
+			Prompt: &survey.Select{
+				Message: "Status:",
+				Options: billableStatuses,
+				Default: statusDefault,
+			},
+		})
+	}
+
+	if preset.Description == "" {
 		taskSurvey = append(taskSurvey, &survey.Question{
 			Name:   "Description",
 			Prompt: &survey.Input{Message: "Description:"},
 		})
+	}
 
-		err = survey.Ask(taskSurvey, &taskInfo)
+	if len(taskSurvey) > 0 {
+		err := survey.Ask(taskSurvey, &taskInfo)
 		check(err)
+	}
 
-		homeDir := _getHomeDir()
-		logFile, err := os.OpenFile(homeDir+"/.timer/logs/"+day, os.O_APPEND|os.O_WRONLY, 0644)
-		check(err)
-		defer logFile.Close()
+	taskInfo.JobType = _resolveJobType(task, taskInfo.JobType)
 
-		_, err = logFile.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s\n", task, formattedDuration, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339), base64.StdEncoding.EncodeToString([]byte(taskInfo.Description))))
-		check(err)
+	return taskInfo
+}
+
+func _submitUpstreamWorkLog(task, profile string, taskInfo TaskDescription, start time.Time, seconds int64) UpstreamSync {
+	provider, found := _providerForTask(task, profile)
 
-		_removeStatusFile()
+	if !found {
+		return UpstreamSync{State: syncStateNone}
+	}
 
-		fmt.Println(fmt.Sprintf("Stopped %s %s elapsed.", task, formattedDuration))
+	// trackers reject empty worklogs, e.g. of a task stopped straight after starting
+	if seconds <= 0 {
+		fmt.Println(fmt.Sprintf("No time was tracked, a %s worklog was not created.", provider.Name()))
+		return UpstreamSync{State: syncStateNone}
+	}
 
-		if config.upstream_service != "" {
-			if config.upstream_service == "gitlab" {
-				if isGitlabTaskFormat(task) {
-					var didSubmitLog bool
+	issue, err := provider.ResolveIssue(task)
 
-					if checkAndLoadGitlabIssue(task) {
-						var seconds int64 = endTime.Sub(startTime).Milliseconds() / 1000
+	if err == nil {
+		err = provider.SubmitWorkLog(issue, WorkLog{Info: taskInfo, Start: start, Seconds: seconds})
+	}
 
-						didSubmitLog = submitGitlabTimeSpent(taskInfo, seconds)
-					}
+	if err != nil {
+		fmt.Println(fmt.Sprintf("Warning: %s looks like a %s issue identifier, this issue is either not found or an error occured. A %s worklog was not created for this time period.", task, provider.Name(), provider.Name()))
+		_printUpstreamError(err)
+	}
 
-					if didSubmitLog != true {
-						fmt.Println(fmt.Sprintf("Warning: %s looks like a gitlab issue branch, this issue is either not found or an error occured. A gitlab worklog was not created for this time period.", task))
-					}
-				}
-			}
+	return _upstreamSyncResult(provider.Name(), err)
+}
 
-			if config.upstream_service == "jira" {
-				if isJiraTaskFormat(task) {
-					var didSubmitLog bool
+// upstream failures after the time is logged are warnings rather than errors.
+func _printUpstreamError(err error) {
+	fmt.Println(" ", err)
+}
 
-					if _checkAndLoadJiraIssue(task) {
-						var seconds int64 = endTime.Sub(startTime).Milliseconds() / 1000
+// whether the task identifier matches the task format of its upstream profile.
+func _isUpstreamTask(task, profile string) bool {
+	_, found := _providerForTask(task, profile)
 
-						didSubmitLog = _submitJiraWorkLog(jiraCurrentTask.Key, taskInfo, seconds)
-					}
+	return found
+}
 
-					if didSubmitLog != true {
-						fmt.Println(fmt.Sprintf("Warning: %s looks like a jira task identifier, this is either not found or an error occured. A jira worklog was not created for this time period.", task))
-					}
-				}
-			}
+func _upstreamSyncResult(service string, err error) UpstreamSync {
+	if err == nil {
+		now := time.Now()
+
+		return UpstreamSync{Service: service, State: syncStateSynced, SyncedAt: &now}
+	}
+
+	if _isPermanentUpstreamError(err) {
+		return UpstreamSync{Service: service, State: syncStateRejected}
+	}
+
+	return UpstreamSync{Service: service, State: syncStateFailed}
+}
+
+/**
+ * Add
+ * Log time for a task after the fact without starting a timer.
+ */
+func add(task, from, to, date string, push bool, preset TaskDescription, noPrompt bool) {
+	if task == "" || from == "" || to == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	task, profile := _routeTaskHere(task)
+	day := _parseDay(date)
+	startTime := _parseEntryTime(day + " " + from)
+	endTime := _parseEntryTime(day + " " + to)
+
+	if !endTime.After(startTime) {
+		check(&UsageError{Message: "an entry must end after it starts"})
+	}
+
+	if time.Now().Before(endTime) {
+		check(&UsageError{Message: "cannot add time in the future"})
+	}
+
+	if overlap, found := _findOverlappingEntry(startTime, endTime, ""); found {
+		check(&UsageError{Message: fmt.Sprintf("overlaps %s logged %s to %s", overlap.Task, overlap.Start.Local().Format(entryTimeFormat), overlap.End.Local().Format(entryTimeFormat))})
+	}
+
+	if _statusFileExists() {
+		status, err := _readStatusFile()
+		check(err)
+
+		if status.Start.Before(endTime) {
+			check(&UsageError{Message: fmt.Sprintf("overlaps %s which is currently being tracked", status.Task)})
 		}
+	}
 
-	} else {
-		fmt.Println("No task started.")
+	fmt.Println(fmt.Sprintf("Adding %s...", task))
+
+	taskInfo := _askTaskDescription(task, preset, noPrompt)
+
+	entry := _newLogEntry(task, startTime, endTime, int64(endTime.Sub(startTime)/time.Second), taskInfo)
+	entry.Profile = profile
+	check(_appendLogEntry(entry))
+
+	fmt.Println(fmt.Sprintf("Added %s %s on %s.", task, _formatDuration(_entryDuration(entry)), day))
+
+	if push {
+		_syncLogEntry(entry, taskInfo)
 	}
 	os.Exit(0)
 }
@@ -205,7 +458,11 @@ func stop(atTime string) {
  */
 func cancel() {
 	if _statusFileExists() {
-		_removeStatusFile()
+		status, err := _readStatusFile()
+		check(err)
+		check(_removeStatusFile())
+
+		_sendWebhook(_cancelWebhookEvent(status, time.Now()))
 	} else {
 		fmt.Println("No task started.")
 	}
@@ -218,25 +475,17 @@ func logDay(dateTime time.Time) {
 	fmt.Println(dateTime.Format("January 2, 2006"))
 
 	if _logFileExists(day) {
-		homeDir := _getHomeDir()
-		file, err := os.Open(homeDir + "/.timer/logs/" + day)
-		check(err)
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
 		writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
 		var dayTotalMs int64 = 0
 
-		for scanner.Scan() {
-			log := strings.Split(scanner.Text(), ",")
-
-			startTime, err := time.Parse(time.RFC3339, log[2])
-			check(err)
+		entries, err := _readLogFile(day)
+		check(err)
 
-			endTime, err := time.Parse(time.RFC3339, log[3])
-			check(err)
+		for _, entry := range entries {
+			startTime := entry.Start
+			endTime := entry.End
 
-			dayTotalMs += endTime.Sub(startTime).Milliseconds()
+			dayTotalMs += _entryDuration(entry).Milliseconds()
 
 			endFormat := "15:04"
 
@@ -247,40 +496,275 @@ func logDay(dateTime time.Time) {
 				endFormat = "15:04 2006-01-02"
 			}
 
-			description, _ := base64.StdEncoding.DecodeString(log[4])
-
-			fmt.Fprintln(writer, fmt.Sprintf("\t%s\t%s\t%s to %s\t%s", log[0], log[1], startTime.Format("15:04"), endTime.Format(endFormat), description))
+			fmt.Fprintln(writer, fmt.Sprintf("\t%s\t%s\t%s to %s\t%s", entry.Task, _formatDuration(_entryDuration(entry)), startTime.Format("15:04"), endTime.Format(endFormat), entry.Description))
 		}
 		var totalDuration time.Duration = time.Duration(dayTotalMs) * time.Millisecond
 		fmt.Fprintln(writer, "\tTotal:", _formatDuration(totalDuration))
 
 		writer.Flush()
 
-		check(scanner.Err())
-
 	} else {
 		fmt.Println("\t-")
 	}
 }
 
 func logFromTo(from, to string) {
-	fromDate, err := time.Parse("2006-01-02", from)
+	days, err := _daysInRange(from, to)
 	check(err)
 
-	toDate, err := time.Parse("2006-01-02", to)
+	for _, day := range days {
+		dateTime, err := time.Parse("2006-01-02", day)
+		check(err)
+
+		logDay(dateTime)
+	}
+}
+
+/**
+ * Migrate
+ * Rewrite day files still in the legacy comma separated format as versioned log entries.
+ */
+func migrate() {
+	var migrated int
+
+	days, err := _listLogDays()
 	check(err)
 
-	if toDate.Before(fromDate) {
-		fmt.Println(fmt.Sprintf("Error, cannot log from %s to %s", fromDate.Format("2006-01-02"), toDate.Format("2006-01-02")))
-		os.Exit(1)
+	for _, day := range days {
+		data, err := os.ReadFile(_logFilePath(day))
+		if err != nil {
+			check(&StorageError{Path: _logFilePath(day), Message: "unable to read log file", Err: err})
+		}
+
+		isLegacy := false
+
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+
+			if line != "" && !strings.HasPrefix(line, "{") {
+				isLegacy = true
+				break
+			}
+		}
+
+		if !isLegacy {
+			continue
+		}
+
+		entries, err := _readLogFile(day)
+		check(err)
+		check(_writeLogFile(day, entries))
+
+		fmt.Println(fmt.Sprintf("Migrated %s, %d entries.", day, len(entries)))
+		migrated++
+	}
+
+	if migrated == 0 {
+		fmt.Println("No log files to migrate.")
+	}
+	os.Exit(0)
+}
+
+/**
+ * Edit
+ * Pick a logged entry of a day and change its task, times, job type or description.
+ */
+func editEntry(date string) {
+	day := _parseDay(date)
+	entries, index := _selectLogEntry(day, "Edit entry:")
+	entry := entries[index]
+
+	var answers struct {
+		Task        string
+		Start       string
+		End         string
+		JobType     string
+		Status      string
+		Description string
 	}
 
-	for {
-		logDay(fromDate)
+	jobTypeOptions, jobTypeDefault := _jobTypeOptions(entry.Task, entry.JobType)
+
+	var editSurvey = []*survey.Question{
+		{
+			Name:     "Task",
+			Prompt:   &survey.Input{Message: "Task:", Default: entry.Task},
+			Validate: survey.Required,
+		},
+		{
+			Name:     "Start",
+			Prompt:   &survey.Input{Message: "Start (yyyy-mm-dd hh:mm):", Default: entry.Start.Local().Format(entryTimeFormat)},
+			Validate: _validateEntryTime,
+		},
+		{
+			Name:     "End",
+			Prompt:   &survey.Input{Message: "End (yyyy-mm-dd hh:mm):", Default: entry.End.Local().Format(entryTimeFormat)},
+			Validate: _validateEntryTime,
+		},
+		{
+			Name: "JobType",
+			Prompt: &survey.Select{
+				Message: "JobType:",
+				Options: jobTypeOptions,
+				Default: jobTypeDefault,
+			},
+		},
+	}
 
-		if !fromDate.Before(toDate) {
-			break
+	if config.billable_enable {
+		statusDefault := entry.Status
+		if statusDefault == "" {
+			statusDefault = "Billable"
 		}
-		fromDate = fromDate.Add(time.Hour * 24)
+
+		editSurvey = append(editSurvey, &survey.Question{
+			Name: "Status",
+			Prompt: &survey.Select{
+				Message: "Status:",
+				Options: billableStatuses,
+				Default: statusDefault,
+			},
+		})
+	}
+
+	editSurvey = append(editSurvey, &survey.Question{
+		Name:   "Description",
+		Prompt: &survey.Input{Message: "Description:", Default: entry.Description},
+	})
+
+	err := survey.Ask(editSurvey, &answers)
+	check(err)
+
+	entry, err = _applyEntryEdit(entry, answers.Task, _parseEntryTime(answers.Start), _parseEntryTime(answers.End))
+	check(err)
+
+	entry.JobType = _resolveJobType(entry.Task, answers.JobType)
+	entry.Description = answers.Description
+
+	if config.billable_enable {
+		entry.Status = answers.Status
+	}
+
+	newDay := _entryDay(entry)
+
+	if newDay != day {
+		// the start date changed, move the entry to its new day file
+		entries = append(entries[:index], entries[index+1:]...)
+		check(_writeLogFile(day, entries))
+
+		newDayEntries, err := _readLogFile(newDay)
+		check(err)
+
+		check(_writeLogFile(newDay, append(newDayEntries, entry)))
+	} else {
+		entries[index] = entry
+		check(_writeLogFile(day, entries))
+	}
+
+	fmt.Println(fmt.Sprintf("Updated %s %s on %s.", entry.Task, _formatDuration(_entryDuration(entry)), newDay))
+	os.Exit(0)
+}
+
+// change the task and times of a logged entry, the profile is routed again when the task changes.
+func _applyEntryEdit(entry LogEntry, task string, startTime, endTime time.Time) (LogEntry, error) {
+	if !endTime.After(startTime) {
+		return entry, &UsageError{Message: "an entry must end after it starts"}
 	}
+
+	// keep the stored duration when the times are unchanged so paused time stays excluded
+	if !startTime.Equal(entry.Start.Truncate(time.Minute)) || !endTime.Equal(entry.End.Truncate(time.Minute)) {
+		entry.Start = startTime
+		entry.End = endTime
+		entry.Seconds = int64(endTime.Sub(startTime) / time.Second)
+	}
+
+	if overlap, found := _findOverlappingEntry(entry.Start, entry.End, entry.Id); found {
+		return entry, &UsageError{Message: fmt.Sprintf("overlaps %s logged %s to %s", overlap.Task, overlap.Start.Local().Format(entryTimeFormat), overlap.End.Local().Format(entryTimeFormat))}
+	}
+
+	if task != entry.Task {
+		entry.Task, entry.Profile = _routeTaskHere(task)
+	}
+
+	return entry, nil
+}
+
+/**
+ * Delete
+ * Pick a logged entry of a day and remove it from the log.
+ */
+func deleteEntry(date string) {
+	day := _parseDay(date)
+	entries, index := _selectLogEntry(day, "Delete entry:")
+	entry := entries[index]
+
+	confirmed := false
+	err := survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Delete %s %s?", entry.Task, _formatDuration(_entryDuration(entry))),
+	}, &confirmed)
+	check(err)
+
+	if confirmed {
+		entries = append(entries[:index], entries[index+1:]...)
+		check(_writeLogFile(day, entries))
+
+		fmt.Println(fmt.Sprintf("Deleted %s from %s.", entry.Task, day))
+	}
+	os.Exit(0)
+}
+
+const entryTimeFormat = "2006-01-02 15:04"
+
+func _parseEntryTime(value string) time.Time {
+	entryTime, err := time.ParseInLocation(entryTimeFormat, strings.TrimSpace(value), time.Local)
+	if err != nil {
+		check(&UsageError{Message: fmt.Sprintf("invalid time %q, expected yyyy-mm-dd hh:mm", value)})
+	}
+
+	return entryTime
+}
+
+func _validateEntryTime(value interface{}) error {
+	_, err := time.ParseInLocation(entryTimeFormat, strings.TrimSpace(value.(string)), time.Local)
+
+	return err
+}
+
+// a yyyy-mm-dd argument or today when empty.
+func _parseDay(date string) string {
+	if date == "" {
+		return time.Now().Format("2006-01-02")
+	}
+
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		check(&UsageError{Message: fmt.Sprintf("invalid date %q, expected yyyy-mm-dd", date)})
+	}
+
+	return day.Format("2006-01-02")
+}
+
+func _selectLogEntry(day, message string) ([]LogEntry, int) {
+	entries, err := _readLogFile(day)
+	check(err)
+
+	if len(entries) == 0 {
+		fmt.Println(fmt.Sprintf("No entries logged on %s.", day))
+		os.Exit(1)
+	}
+
+	var options []string
+
+	for _, entry := range entries {
+		options = append(options, fmt.Sprintf("%s  %s  %s to %s  %s", entry.Task, _formatDuration(_entryDuration(entry)), entry.Start.Local().Format("15:04"), entry.End.Local().Format("15:04"), entry.Description))
+	}
+
+	var index int
+	err = survey.AskOne(&survey.Select{
+		Message: message,
+		Options: options,
+	}, &index)
+	check(err)
+
+	return entries, index
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyEntryEdit(t *testing.T) {
+	_useTestHome(t)
+
+	profiles := config.Profiles
+	t.Cleanup(func() { config.Profiles = profiles })
+
+	config.Profiles = []UpstreamProfile{
+		{Name: "client", Service: "jira", Match: "^CL-", Settings: map[string]string{}},
+		{Name: "internal", Service: "jira", Match: "^INT-", Settings: map[string]string{}},
+	}
+
+	start := time.Date(2026, 10, 16, 9, 0, 30, 0, time.Local)
+	first := _newLogEntry("CL-1", start, start.Add(time.Hour), 3000, TaskDescription{})
+	first.Profile = "client"
+	second := _newLogEntry("CL-2", start.Add(time.Hour), start.Add(2*time.Hour), 3600, TaskDescription{})
+	second.Profile = "client"
+
+	if err := _writeLogFile("2026-10-16", []LogEntry{first, second}); err != nil {
+		t.Fatal(err)
+	}
+
+	// unchanged times keep the stored duration and don't overlap the entries either side
+	edited, err := _applyEntryEdit(second, "INT-5", start.Add(time.Hour).Truncate(time.Minute), start.Add(2*time.Hour).Truncate(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if edited.Task != "INT-5" || edited.Profile != "internal" || edited.Seconds != 3600 || !edited.Start.Equal(second.Start) {
+		t.Errorf("unexpected edited entry %+v", edited)
+	}
+
+	// a prefixed profile is routed like a started task
+	if edited, _ := _applyEntryEdit(second, "client:INT-5", second.Start, second.End); edited.Task != "INT-5" || edited.Profile != "client" {
+		t.Errorf("unexpected edited entry %+v", edited)
+	}
+
+	if _, err := _applyEntryEdit(second, "CL-2", start.Add(30*time.Minute), start.Add(2*time.Hour)); err == nil {
+		t.Error("expected an error for an overlapping entry")
+	}
+
+	if _, err := _applyEntryEdit(second, "CL-2", second.End, second.Start); err == nil {
+		t.Error("expected an error for an entry ending before it starts")
+	}
+
+	moved, err := _applyEntryEdit(second, "CL-2", start.Add(3*time.Hour).Truncate(time.Minute), start.Add(4*time.Hour).Truncate(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if moved.Seconds != 3600 || moved.Profile != "client" {
+		t.Errorf("unexpected moved entry %+v", moved)
+	}
+}
@@ -10,13 +10,26 @@ import (
 
 func ps1Complication() {
 	if _statusFileExists() {
-		task, startTimeString := _readStatusFile()
-		startTime, err := time.Parse(time.RFC3339, startTimeString)
+		status, err := _readStatusFile()
 		check(err)
 
-		formattedDiff := _formatDuration(time.Since(startTime))
+		elapsed := _netDuration(status, time.Now())
+		formattedDiff := _formatDuration(elapsed)
+		task := status.Task
 
-		fmt.Print(fmt.Sprintf("%s %s", task, formattedDiff))
+		if cached, found := _readIssueCache(status.Task); found {
+			task = _issueSummary(cached, elapsed, 30)
+
+			if _overEstimateSeconds(cached, elapsed) > 0 {
+				task += " (over estimate)"
+			}
+		}
+
+		if _isPaused(status) {
+			fmt.Print(fmt.Sprintf("%s %s (paused)", task, formattedDiff))
+		} else {
+			fmt.Print(fmt.Sprintf("%s %s", task, formattedDiff))
+		}
 	} else {
 		fmt.Print("<No task>")
 	}
@@ -34,16 +47,8 @@ func preCmd() {
 		if isGit && cwd != pwd {
 			head := _getHeadRef()
 			branchLeader := strings.Split(head, "/")[2]
-			var isPossibleTaskIdent = false
-
-			if config.upstream_service != "" {
-				switch config.upstream_service {
-				case "jira":
-					isPossibleTaskIdent = isJiraTaskFormat(branchLeader)
-				case "gitlab":
-					isPossibleTaskIdent = isGitlabTaskFormat(branchLeader)
-				}
-			}
+			_, profile := _routeTaskHere(branchLeader)
+			var isPossibleTaskIdent = _isUpstreamTask(branchLeader, profile)
 
 			reassign := !isPossibleTaskIdent
 			reader := bufio.NewReader(os.Stdin)
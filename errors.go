@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+)
+
+// exit codes per error class
+const (
+	exitError     = 1
+	exitUsage     = 2
+	exitConfig    = 3
+	exitStorage   = 4
+	exitUpstream  = 5
+	exitInterrupt = 130
+)
+
+// set with --debug, errors then panic with the full trace instead of printing a message.
+var debugMode = false
+
+type UsageError struct {
+	Message string
+	Err     error
+}
+
+func (e *UsageError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *UsageError) Unwrap() error { return e.Err }
+
+type ConfigError struct {
+	Message string
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+type StorageError struct {
+	Path    string
+	Message string
+	Err     error
+}
+
+func (e *StorageError) Error() string {
+	message := e.Message + " (" + e.Path + ")"
+
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+func (e *StorageError) Unwrap() error { return e.Err }
+
+type UpstreamError struct {
+	Service    string
+	Message    string
+	StatusCode int
+	Err        error
+}
+
+func (e *UpstreamError) Error() string {
+	message := e.Service + ": " + e.Message
+
+	if e.StatusCode != 0 {
+		message += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+func (e *UpstreamError) Unwrap() error { return e.Err }
+
+// client errors of the upstream other than auth and rate limits, such as a deleted issue or a rejected worklog.
+func _isPermanentUpstreamError(err error) bool {
+	var upstreamError *UpstreamError
+
+	if !errors.As(err, &upstreamError) {
+		return false
+	}
+
+	switch upstreamError.StatusCode {
+	case 401, 403, 429:
+		return false
+	}
+
+	return upstreamError.StatusCode >= 400 && upstreamError.StatusCode < 500
+}
+
+func _exitCode(err error) int {
+	var usageError *UsageError
+	var configError *ConfigError
+	var storageError *StorageError
+	var upstreamError *UpstreamError
+
+	switch {
+	case errors.Is(err, terminal.InterruptErr):
+		return exitInterrupt
+	case errors.As(err, &usageError):
+		return exitUsage
+	case errors.As(err, &configError):
+		return exitConfig
+	case errors.As(err, &storageError):
+		return exitStorage
+	case errors.As(err, &upstreamError):
+		return exitUpstream
+	}
+
+	return exitError
+}
+
+// print a readable message for a panic that escaped a command, in debug mode the panic and its trace are left alone.
+func _recoverPanic() {
+	if debugMode {
+		return
+	}
+
+	if r := recover(); r != nil {
+		if err, ok := r.(error); ok {
+			check(err)
+		}
+
+		fmt.Fprintln(os.Stderr, "Error:", r, "(run with --debug for details)")
+		os.Exit(exitError)
+	}
+}
+
+func check(e error) {
+	if e != nil {
+		if debugMode {
+			panic(e)
+		}
+
+		if errors.Is(e, terminal.InterruptErr) {
+			os.Exit(exitInterrupt)
+		}
+
+		fmt.Fprintln(os.Stderr, "Error:", e)
+		os.Exit(_exitCode(e))
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var exportFormats = []string{"csv", "json", "ics"}
+
+var csvExportHeader = []string{"id", "task", "start", "end", "seconds", "duration", "job_type", "status", "description", "upstream_service", "upstream_state"}
+
+/**
+ * Export
+ * Write logged entries of a date range as csv, json or an icalendar file to stdout or a file.
+ */
+func export(format, from, to, outputPath string) {
+	if !_contains(exportFormats, format) {
+		check(&UsageError{Message: fmt.Sprintf("unknown export format %q, expected one of %v", format, exportFormats)})
+	}
+
+	var entries []LogEntry
+
+	days, err := _daysInRange(from, to)
+	check(err)
+
+	for _, day := range days {
+		dayEntries, err := _readLogFile(day)
+		check(err)
+
+		entries = append(entries, dayEntries...)
+	}
+
+	var output io.Writer = os.Stdout
+
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		check(err)
+		defer file.Close()
+
+		output = file
+	}
+
+	switch format {
+	case "csv":
+		_exportCsv(output, entries)
+	case "json":
+		_exportJson(output, entries)
+	case "ics":
+		_exportIcs(output, entries)
+	}
+
+	if outputPath != "" {
+		fmt.Println(fmt.Sprintf("Exported %d entries to %s.", len(entries), outputPath))
+	}
+}
+
+func _exportCsv(output io.Writer, entries []LogEntry) {
+	writer := csv.NewWriter(output)
+	writer.UseCRLF = true
+
+	err := writer.Write(csvExportHeader)
+	check(err)
+
+	for _, entry := range entries {
+		err = writer.Write([]string{
+			entry.Id,
+			entry.Task,
+			entry.Start.Format(time.RFC3339),
+			entry.End.Format(time.RFC3339),
+			strconv.FormatInt(entry.Seconds, 10),
+			_formatDuration(_entryDuration(entry)),
+			entry.JobType,
+			entry.Status,
+			entry.Description,
+			entry.Upstream.Service,
+			entry.Upstream.State,
+		})
+		check(err)
+	}
+
+	writer.Flush()
+	check(writer.Error())
+}
+
+func _exportJson(output io.Writer, entries []LogEntry) {
+	if entries == nil {
+		entries = []LogEntry{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "\t")
+	check(err)
+
+	_, err = output.Write(append(data, '\n'))
+	check(err)
+}
+
+func _exportIcs(output io.Writer, entries []LogEntry) {
+	const icsTimeFormat = "20060102T150405Z"
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//timer//timer//EN",
+		"CALSCALE:GREGORIAN",
+	}
+
+	stamp := time.Now().UTC().Format(icsTimeFormat)
+
+	for _, entry := range entries {
+		summary := entry.Task
+		if entry.JobType != "" {
+			summary += " (" + entry.JobType + ")"
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+entry.Id+"@timer",
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+entry.Start.UTC().Format(icsTimeFormat),
+			"DTEND:"+entry.End.UTC().Format(icsTimeFormat),
+			"SUMMARY:"+_escapeIcsText(summary),
+		)
+
+		if entry.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+_escapeIcsText(entry.Description))
+		}
+
+		if entry.Status != "" {
+			lines = append(lines, "CATEGORIES:"+_escapeIcsText(entry.Status))
+		}
+
+		lines = append(lines, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		_, err := io.WriteString(output, _foldIcsLine(line)+"\r\n")
+		check(err)
+	}
+}
+
+func _escapeIcsText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+	return replacer.Replace(text)
+}
+
+// fold content lines longer than 75 octets without splitting multi-byte characters, per RFC 5545.
+func _foldIcsLine(line string) string {
+	var builder strings.Builder
+	lineLength := 0
+
+	for _, r := range line {
+		size := len(string(r))
+
+		if lineLength+size > 75 {
+			builder.WriteString("\r\n ")
+			lineLength = 1
+		}
+
+		builder.WriteRune(r)
+		lineLength += size
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFoldIcsLine(t *testing.T) {
+	for _, line := range []string{
+		"SUMMARY:ABC-1",
+		"DESCRIPTION:" + strings.Repeat("a", 63),
+		"DESCRIPTION:" + strings.Repeat("fixed the login form ", 12),
+		"DESCRIPTION:" + strings.Repeat("überprüft ", 20),
+		"SUMMARY:" + strings.Repeat("日本語", 30),
+	} {
+		folded := _foldIcsLine(line)
+
+		if len(line) <= 75 && folded != line {
+			t.Errorf("expected %q to be unchanged, got %q", line, folded)
+		}
+
+		for _, part := range strings.Split(folded, "\r\n") {
+			// continuation lines include their leading space
+			if len(part) > 75 {
+				t.Errorf("folded line of %d octets %q", len(part), part)
+			}
+			if !utf8.ValidString(part) {
+				t.Errorf("folded line splits a character %q", part)
+			}
+		}
+
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+			t.Errorf("unfolded %q, expected %q", unfolded, line)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type GiteaConfig struct {
+	Url               string
+	Token             string
+	DefaultRepository string
+}
+
+type GiteaIssue struct {
+	Number int32  `json:"number"`
+	State  string `json:"state"`
+	Title  string `json:"title"`
+}
+
+type GiteaProvider struct {
+	name   string
+	config GiteaConfig
+	client *http.Client
+}
+
+func init() {
+	// forgejo is a fork of gitea with the same api
+	for _, service := range []string{"gitea", "forgejo"} {
+		name := service
+
+		_registerProvider(name, func(settings map[string]string) TrackerProvider {
+			return &GiteaProvider{
+				name: name,
+				config: GiteaConfig{
+					Url:               strings.TrimSuffix(settings["url"], "/"),
+					Token:             settings["token"],
+					DefaultRepository: settings["default_repository"],
+				},
+				client: &http.Client{Timeout: 10 * time.Second},
+			}
+		})
+	}
+}
+
+func (p *GiteaProvider) Name() string {
+	return p.name
+}
+
+// same 123-slug branch names as gitlab
+func (p *GiteaProvider) MatchesTask(task string) bool {
+	return isGitlabTaskFormat(task)
+}
+
+// owner/repo of the origin remote when it is hosted on the configured server, otherwise the default repository.
+func (p *GiteaProvider) repository() (string, error) {
+	host, path := _parseGitRemote(_getGitRemoteUrl("origin"))
+
+	instance, err := url.Parse(p.config.Url)
+	if err != nil {
+		return "", &ConfigError{Message: "invalid " + p.name + " url", Err: err}
+	}
+
+	if host != "" && host == instance.Hostname() && strings.Count(path, "/") == 1 {
+		return path, nil
+	}
+
+	if p.config.DefaultRepository != "" {
+		return p.config.DefaultRepository, nil
+	}
+
+	return "", &ConfigError{Message: fmt.Sprintf("%s requires a git origin remote on %s or default_repository in the config", p.name, p.config.Url)}
+}
+
+func (p *GiteaProvider) loadIssue(repository, number string) (TrackerIssue, error) {
+	response, err := p.apiRequest("GET", fmt.Sprintf("/repos/%s/issues/%s", repository, number), nil)
+	if err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: p.name, Message: "unable to reach " + p.name, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return TrackerIssue{}, &UpstreamError{Service: p.name, Message: fmt.Sprintf("issue %s#%s not found", repository, number), StatusCode: response.StatusCode}
+	}
+
+	var issue GiteaIssue
+	if err := json.NewDecoder(response.Body).Decode(&issue); err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: p.name, Message: "unexpected issue response", Err: err}
+	}
+
+	return TrackerIssue{
+		Key:     fmt.Sprint(issue.Number),
+		Project: repository,
+		Title:   issue.Title,
+		State:   issue.State,
+		Open:    issue.State == "open",
+	}, nil
+}
+
+func (p *GiteaProvider) ResolveIssue(task string) (TrackerIssue, error) {
+	if p.config.Url == "" || p.config.Token == "" {
+		return TrackerIssue{}, &ConfigError{Message: p.name + " requires url and token in the config"}
+	}
+
+	repository, err := p.repository()
+	if err != nil {
+		return TrackerIssue{}, err
+	}
+
+	number := regexp.MustCompile(`^[0-9]+`).FindString(task)
+	if number == "" {
+		return TrackerIssue{}, &UsageError{Message: fmt.Sprintf("%s does not start with an issue number", task)}
+	}
+
+	return p.loadIssue(repository, number)
+}
+
+func (p *GiteaProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"time":    workLog.Seconds,
+		"created": workLog.Start,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := p.apiRequest("POST", fmt.Sprintf("/repos/%s/issues/%s/times", issue.Project, issue.Key), body)
+	if err != nil {
+		return &UpstreamError{Service: p.name, Message: "unable to reach " + p.name, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == 200 || response.StatusCode == 201 {
+		return nil
+	}
+
+	return &UpstreamError{Service: p.name, Message: fmt.Sprintf("unable to add tracked time to %s#%s", issue.Project, issue.Key), StatusCode: response.StatusCode}
+}
+
+func (p *GiteaProvider) apiRequest(method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.config.Url+"/api/v1"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "token "+p.config.Token)
+	req.Header.Add("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return p.client.Do(req)
+}
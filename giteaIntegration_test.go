@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGiteaRepository(t *testing.T) {
+	_useTestHome(t)
+
+	if err := os.MkdirAll(".git", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := trackerProviders["gitea"](map[string]string{"url": "https://git.example.com", "default_repository": "acme/default"}).(*GiteaProvider)
+
+	for remote, expected := range map[string]string{
+		"git@git.example.com:acme/web.git":       "acme/web",
+		"https://git.example.com/acme/web":       "acme/web",
+		"git@example.com:acme/web.git":           "acme/default",
+		"https://gitlab.com/acme/web.git":        "acme/default",
+		"https://git.example.com/acme/web/extra": "acme/default",
+	} {
+		if err := os.WriteFile(".git/config", []byte("[remote \"origin\"]\n\turl = "+remote+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		repository, err := provider.repository()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if repository != expected {
+			t.Errorf("repository of remote %s = %s, expected %s", remote, repository, expected)
+		}
+	}
+}
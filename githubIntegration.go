@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type GithubConfig struct {
+	Url               string
+	Token             string
+	DefaultRepository string
+}
+
+type GithubIssue struct {
+	Number  int32  `json:"number"`
+	State   string `json:"state"`
+	Title   string `json:"title"`
+	HtmlUrl string `json:"html_url"`
+}
+
+type GithubProvider struct {
+	config GithubConfig
+	client *http.Client
+}
+
+// #123, owner/repo#123 or a 123-branch-name
+var githubTaskFormat = regexp.MustCompile(`^(?:([\w.-]+/[\w.-]+))?#([0-9]+)$|^([0-9]+)-[\w.-]+$`)
+
+func init() {
+	_registerProvider("github", func(settings map[string]string) TrackerProvider {
+		apiUrl := settings["url"]
+		if apiUrl == "" {
+			apiUrl = "https://api.github.com"
+		}
+
+		return &GithubProvider{
+			config: GithubConfig{
+				Url:               strings.TrimSuffix(apiUrl, "/"),
+				Token:             settings["token"],
+				DefaultRepository: settings["default_repository"],
+			},
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	})
+}
+
+func isGithubTaskFormat(identifier string) bool {
+	return githubTaskFormat.MatchString(identifier)
+}
+
+func (p *GithubProvider) Name() string {
+	return "github"
+}
+
+func (p *GithubProvider) MatchesTask(task string) bool {
+	return isGithubTaskFormat(task)
+}
+
+// owner/repo and issue number of a task, the repository falls back to the config then the origin remote.
+func (p *GithubProvider) parseTask(task string) (string, string, error) {
+	match := githubTaskFormat.FindStringSubmatch(task)
+	if match == nil {
+		return "", "", &UsageError{Message: fmt.Sprintf("%s is not a github issue identifier", task)}
+	}
+
+	repository, number := match[1], match[2]
+	if number == "" {
+		number = match[3]
+	}
+
+	if repository == "" {
+		repository = p.config.DefaultRepository
+	}
+
+	if repository == "" {
+		if host, path := _parseGitRemote(_getGitRemoteUrl("origin")); strings.Contains(host, "github") {
+			repository = path
+		}
+	}
+
+	if repository == "" {
+		return "", "", &ConfigError{Message: "github requires owner/repo#number, default_repository in the config or a github origin remote"}
+	}
+
+	return repository, number, nil
+}
+
+func (p *GithubProvider) loadIssue(repository, number string) (TrackerIssue, error) {
+	response, err := p.apiRequest("GET", fmt.Sprintf("/repos/%s/issues/%s", repository, number), nil)
+	if err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: "github", Message: "unable to reach github", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return TrackerIssue{}, &UpstreamError{Service: "github", Message: fmt.Sprintf("issue %s#%s not found", repository, number), StatusCode: response.StatusCode}
+	}
+
+	var issue GithubIssue
+	if err := json.NewDecoder(response.Body).Decode(&issue); err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: "github", Message: "unexpected issue response", Err: err}
+	}
+
+	return TrackerIssue{
+		Key:     fmt.Sprint(issue.Number),
+		Project: repository,
+		Title:   issue.Title,
+		State:   issue.State,
+		Open:    issue.State == "open",
+	}, nil
+}
+
+func (p *GithubProvider) ResolveIssue(task string) (TrackerIssue, error) {
+	if p.config.Token == "" {
+		return TrackerIssue{}, &ConfigError{Message: "github requires a token in the config"}
+	}
+
+	repository, number, err := p.parseTask(task)
+	if err != nil {
+		return TrackerIssue{}, err
+	}
+
+	return p.loadIssue(repository, number)
+}
+
+// github has no time tracking, the worklog is recorded as an issue comment with a machine readable summary.
+func (p *GithubProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	end := workLog.Start.Add(time.Duration(workLog.Seconds) * time.Second)
+
+	summary, err := json.Marshal(map[string]interface{}{
+		"seconds":     workLog.Seconds,
+		"start":       workLog.Start.Format(time.RFC3339),
+		"end":         end.Format(time.RFC3339),
+		"job_type":    workLog.Info.JobType,
+		"status":      workLog.Info.Status,
+		"description": workLog.Info.Description,
+	})
+	if err != nil {
+		return err
+	}
+
+	comment := fmt.Sprintf("**Time logged:** %s\n\n| Start | End | Job Type |", _formatSeconds(workLog.Seconds))
+	row := fmt.Sprintf("| %s | %s | %s |", workLog.Start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), workLog.Info.JobType)
+	divider := "| --- | --- | --- |"
+
+	if config.billable_enable {
+		comment = strings.TrimSuffix(comment, "|") + "| Status |"
+		row += fmt.Sprintf(" %s |", workLog.Info.Status)
+		divider += " --- |"
+	}
+
+	comment += "\n" + divider + "\n" + row
+
+	if workLog.Info.Description != "" {
+		comment += "\n\n" + workLog.Info.Description
+	}
+
+	comment += fmt.Sprintf("\n\n<!-- timer:worklog %s -->", summary)
+
+	body, err := json.Marshal(map[string]string{"body": comment})
+	if err != nil {
+		return err
+	}
+
+	response, err := p.apiRequest("POST", fmt.Sprintf("/repos/%s/issues/%s/comments", issue.Project, issue.Key), body)
+	if err != nil {
+		return &UpstreamError{Service: "github", Message: "unable to reach github", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == 201 {
+		return nil
+	}
+
+	return &UpstreamError{Service: "github", Message: fmt.Sprintf("unable to comment on %s#%s", issue.Project, issue.Key), StatusCode: response.StatusCode}
+}
+
+func (p *GithubProvider) apiRequest(method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.config.Url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+p.config.Token)
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return p.client.Do(req)
+}
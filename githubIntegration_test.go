@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func _newTestGithubProvider(t *testing.T, handler http.HandlerFunc) TrackerProvider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return trackerProviders["github"](map[string]string{
+		"url":                server.URL,
+		"token":              "secret",
+		"default_repository": "acme/default",
+	})
+}
+
+func TestGithubMatchesTask(t *testing.T) {
+	provider := trackerProviders["github"](map[string]string{})
+
+	for task, expected := range map[string]bool{
+		"#12":             true,
+		"acme/web#12":     true,
+		"12-fix-login":    true,
+		"PROJ-12":         false,
+		"acme/web#":       false,
+		"fix login":       false,
+		"acme/web/sub#12": false,
+	} {
+		if provider.MatchesTask(task) != expected {
+			t.Errorf("MatchesTask(%q) = %v, expected %v", task, !expected, expected)
+		}
+	}
+}
+
+func TestGithubResolveIssue(t *testing.T) {
+	var paths []string
+
+	provider := _newTestGithubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Accept") != "application/vnd.github+json" {
+			t.Errorf("unexpected accept %q", r.Header.Get("Accept"))
+		}
+
+		if r.URL.Path == "/repos/acme/web/issues/404" {
+			w.WriteHeader(404)
+			return
+		}
+
+		w.Write([]byte(`{"number":12,"state":"closed","title":"Fix login"}`))
+	})
+
+	issue, err := provider.ResolveIssue("acme/web#12")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issue.Key != "12" || issue.Project != "acme/web" || issue.Title != "Fix login" || issue.Open {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+
+	if _, err := provider.ResolveIssue("12-fix-login"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.ResolveIssue("acme/web#404"); err == nil {
+		t.Error("expected an error for a missing issue")
+	}
+
+	expected := []string{"/repos/acme/web/issues/12", "/repos/acme/default/issues/12", "/repos/acme/web/issues/404"}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Errorf("requested %v, expected %v", paths, expected)
+	}
+}
+
+func TestGithubSubmitWorkLog(t *testing.T) {
+	var path, comment string
+
+	provider := _newTestGithubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		comment = body["body"]
+
+		w.WriteHeader(201)
+	})
+
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	err := provider.SubmitWorkLog(TrackerIssue{Key: "12", Project: "acme/web"}, WorkLog{
+		Info:    TaskDescription{JobType: "Code Review", Status: "Billable", Description: "fixed it"},
+		Start:   start,
+		Seconds: 5400,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "POST /repos/acme/web/issues/12/comments" {
+		t.Errorf("unexpected request %s", path)
+	}
+
+	if !strings.HasPrefix(comment, "**Time logged:** 1h 30m 0s") || !strings.Contains(comment, "| Code Review |") || !strings.Contains(comment, "\n\nfixed it\n\n") {
+		t.Errorf("unexpected comment %q", comment)
+	}
+
+	match := regexp.MustCompile(`<!-- timer:worklog (.*) -->$`).FindStringSubmatch(comment)
+	if match == nil {
+		t.Fatalf("comment has no worklog summary %q", comment)
+	}
+
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(match[1]), &summary); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary["seconds"] != float64(5400) || summary["start"] != "2026-10-17T09:00:00Z" || summary["end"] != "2026-10-17T10:30:00Z" || summary["job_type"] != "Code Review" {
+		t.Errorf("unexpected worklog summary %v", summary)
+	}
+}
+
+func TestGithubSubmitWorkLogFailure(t *testing.T) {
+	provider := _newTestGithubProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+	})
+
+	err := provider.SubmitWorkLog(TrackerIssue{Key: "12", Project: "acme/web"}, WorkLog{Start: time.Now(), Seconds: 60})
+
+	if upstreamErr, isUpstream := err.(*UpstreamError); !isUpstream || upstreamErr.StatusCode != 403 {
+		t.Errorf("expected an upstream error with status 403, got %v", err)
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type GitlabConfig struct {
@@ -15,11 +18,6 @@ type GitlabConfig struct {
 	DefaultProject string
 }
 
-type GitlabUser struct {
-	Id       int32  `json:"id"`
-	Username string `json:"username"`
-}
-
 type GitlabProject struct {
 	Id          int32  `json:"id"`
 	Name        string `json:"name"`
@@ -28,10 +26,35 @@ type GitlabProject struct {
 }
 
 type GitlabIssue struct {
-	Id    int32  `json:"id"`
-	Iid   int32  `json:"iid"`
-	State string `json:"state"`
-	Title string `json:"title"`
+	Id        int32                `json:"id"`
+	Iid       int32                `json:"iid"`
+	ProjectId int32                `json:"project_id"`
+	State     string               `json:"state"`
+	Title     string               `json:"title"`
+	TimeStats GitlabIssueTimeStats `json:"time_stats"`
+}
+
+type GitlabIssueTimeStats struct {
+	TimeEstimate   int64 `json:"time_estimate"`
+	TotalTimeSpent int64 `json:"total_time_spent"`
+}
+
+type GitlabProvider struct {
+	config GitlabConfig
+	client *http.Client
+}
+
+func init() {
+	_registerProvider("gitlab", func(settings map[string]string) TrackerProvider {
+		return &GitlabProvider{
+			config: GitlabConfig{
+				Url:            settings["url"],
+				Token:          settings["token"],
+				DefaultProject: settings["default_gitlab_project_id"],
+			},
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	})
 }
 
 func isGitlabTaskFormat(identifier string) bool {
@@ -40,102 +63,312 @@ func isGitlabTaskFormat(identifier string) bool {
 	return taskFmt.Match([]byte(identifier))
 }
 
-var gitlabHttpClient = &http.Client{}
-var gitlabUser = GitlabUser{}
-var gitlabProject = GitlabProject{}
-var gitlabIssue = GitlabIssue{}
+// !123
+func isGitlabMergeRequestFormat(identifier string) bool {
+	return regexp.MustCompile(`^![0-9]+$`).MatchString(identifier)
+}
+
+func (p *GitlabProvider) Name() string {
+	return "gitlab"
+}
+
+func (p *GitlabProvider) MatchesTask(task string) bool {
+	return isGitlabTaskFormat(task) || isGitlabMergeRequestFormat(task)
+}
+
+func (p *GitlabProvider) loadProject(id string) (GitlabProject, error) {
+	var project GitlabProject
 
-func loadGitlabUser() bool {
-	response, err := gitlabApiRequest("GET", "/user")
-	check(err)
+	response, err := p.apiRequest("GET", "/projects/"+id)
+	if err != nil {
+		return project, &UpstreamError{Service: "gitlab", Message: "unable to reach gitlab", Err: err}
+	}
 	defer response.Body.Close()
 
 	if response.StatusCode == 200 {
-		json.NewDecoder(response.Body).Decode(&gitlabUser)
+		return project, json.NewDecoder(response.Body).Decode(&project)
+	}
+
+	return project, &UpstreamError{Service: "gitlab", Message: "project " + id + " not found", StatusCode: response.StatusCode}
+}
+
+func (p *GitlabProvider) loadIssue(projectId int32, iid string) (GitlabIssue, error) {
+	var issue GitlabIssue
 
-		return true
+	response, err := p.apiRequest("GET", fmt.Sprintf("/projects/%d/issues/%s", projectId, iid))
+	if err != nil {
+		return issue, &UpstreamError{Service: "gitlab", Message: "unable to reach gitlab", Err: err}
 	}
+	defer response.Body.Close()
 
-	fmt.Println("Warning: Unable to update gitlab, please check your configuration.")
+	if response.StatusCode == 200 {
+		return issue, json.NewDecoder(response.Body).Decode(&issue)
+	}
 
-	return false
+	return issue, &UpstreamError{Service: "gitlab", Message: "issue " + iid + " not found", StatusCode: response.StatusCode}
 }
 
-func loadGitlabProject(id string) bool {
-	response, err := gitlabApiRequest("GET", "/projects/"+id)
-	check(err)
+// merge requests share the fields timer uses with issues.
+func (p *GitlabProvider) loadMergeRequest(projectId int32, iid string) (GitlabIssue, error) {
+	var mergeRequest GitlabIssue
+
+	response, err := p.apiRequest("GET", fmt.Sprintf("/projects/%d/merge_requests/%s", projectId, iid))
+	if err != nil {
+		return mergeRequest, &UpstreamError{Service: "gitlab", Message: "unable to reach gitlab", Err: err}
+	}
 	defer response.Body.Close()
 
 	if response.StatusCode == 200 {
-		json.NewDecoder(response.Body).Decode(&gitlabProject)
+		return mergeRequest, json.NewDecoder(response.Body).Decode(&mergeRequest)
+	}
+
+	return mergeRequest, &UpstreamError{Service: "gitlab", Message: "merge request !" + iid + " not found", StatusCode: response.StatusCode}
+}
+
+// load an issue by its iid or a merge request by !iid.
+func (p *GitlabProvider) loadIssuable(projectId int32, key string) (TrackerIssue, error) {
+	if strings.HasPrefix(key, "!") {
+		mergeRequest, err := p.loadMergeRequest(projectId, strings.TrimPrefix(key, "!"))
+		if err != nil {
+			return TrackerIssue{}, err
+		}
+
+		issue := _gitlabTrackerIssue(mergeRequest)
+		issue.Id = fmt.Sprintf("gid://gitlab/MergeRequest/%d", mergeRequest.Id)
+		issue.Key = "!" + issue.Key
+
+		return issue, nil
+	}
 
-		return true
+	loaded, err := p.loadIssue(projectId, key)
+	if err != nil {
+		return TrackerIssue{}, err
 	}
 
-	return false
+	issue := _gitlabTrackerIssue(loaded)
+	issue.Id = fmt.Sprintf("gid://gitlab/Issue/%d", loaded.Id)
+
+	return issue, nil
 }
 
-func checkAndLoadGitlabIssue(issueKey string) bool {
-	if _configIsComplete() {
-		var didLoadProject = false
+func (p *GitlabProvider) ResolveIssue(issueKey string) (TrackerIssue, error) {
+	if p.config.Url == "" || p.config.Token == "" {
+		return TrackerIssue{}, &ConfigError{Message: "gitlab requires url and token in the config"}
+	}
 
-		if config.GitlabServiceConfig.DefaultProject != "" {
-			didLoadProject = loadGitlabProject(config.GitlabServiceConfig.DefaultProject)
+	projectId, err := p.currentProjectId()
+	if err != nil {
+		return TrackerIssue{}, err
+	}
 
-			if didLoadProject {
-				issueKeyParts := strings.Split(issueKey, "-")
-				response, err := gitlabApiRequest("GET", fmt.Sprintf("/projects/%d/issues/%s", gitlabProject.Id, issueKeyParts[0]))
-				check(err)
-				defer response.Body.Close()
+	issueKeyParts := strings.Split(issueKey, "-")
 
-				if response.StatusCode == 200 {
-					json.NewDecoder(response.Body).Decode(&gitlabIssue)
+	return p.loadIssuable(projectId, issueKeyParts[0])
+}
 
-					return true
-				}
-			}
+// project of the current git repo's remotes, falls back to the default project when none is found on gitlab.
+func (p *GitlabProvider) currentProjectId() (int32, error) {
+	var lookupErr error
 
-			return false
-		} else {
-			// @TODO: search for the current project via name
+	cache, err := _readGitlabProjectCache()
+	if err != nil {
+		return 0, err
+	}
+
+	instance, err := url.Parse(p.config.Url)
+	if err != nil {
+		return 0, &ConfigError{Message: "invalid gitlab url", Err: err}
+	}
+
+	for _, remoteUrl := range _getGitRemoteUrls() {
+		// remotes on other hosts may share a path with an unrelated project of this instance
+		host, path := _parseGitRemote(remoteUrl)
+		if path == "" || host != instance.Hostname() {
+			continue
 		}
+
+		cacheKey := p.config.Url + " " + remoteUrl
+		if projectId, exists := cache[cacheKey]; exists {
+			return projectId, nil
+		}
+
+		// namespace/project is a single url encoded id
+		project, err := p.loadProject(url.PathEscape(path))
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+
+		cache[cacheKey] = project.Id
+		if err := _writeGitlabProjectCache(cache); err != nil {
+			return 0, err
+		}
+
+		return project.Id, nil
 	}
 
-	return false
+	if p.config.DefaultProject != "" {
+		project, err := p.loadProject(p.config.DefaultProject)
+		if err != nil {
+			return 0, err
+		}
+
+		return project.Id, nil
+	}
+
+	if lookupErr != nil {
+		return 0, lookupErr
+	}
+
+	return 0, &ConfigError{Message: "gitlab requires a git remote of a gitlab project or default_gitlab_project_id in the config"}
 }
 
-func submitGitlabTimeSpent(info TaskDescription, seconds int64) bool {
-	if gitlabProject.Id != 0 && gitlabIssue.Iid != 0 { // project and iid are always non-zero
-		var summary string
+// logged with the timelogCreate mutation, the rest add_spent_time endpoint records time as spent now.
+func (p *GitlabProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	if issue.Id == "" {
+		return &UpstreamError{Service: "gitlab", Message: "no issue loaded"}
+	}
 
-		if config.billable_enable {
-			summary = fmt.Sprintf("Job Type: %s\nStatus: %s\nDescription: %s", info.JobType, info.Status, info.Description)
-		} else {
-			summary = fmt.Sprintf("Job Type: %s\nDescription: %s", info.JobType, info.Description)
+	mutation := `mutation($input: TimelogCreateInput!) {
+		timelogCreate(input: $input) {
+			errors
 		}
+	}`
+
+	var result struct {
+		TimelogCreate struct {
+			Errors []string `json:"errors"`
+		} `json:"timelogCreate"`
+	}
+
+	err := p.graphqlRequest(mutation, map[string]interface{}{
+		"input": map[string]interface{}{
+			"issuableId": issue.Id,
+			"timeSpent":  fmt.Sprintf("%ds", workLog.Seconds),
+			"spentAt":    workLog.Start.Format(time.RFC3339),
+			"summary":    _summaryComment(workLog.Info),
+		},
+	}, &result)
+	if err != nil {
+		return err
+	}
+
+	if len(result.TimelogCreate.Errors) > 0 {
+		return &UpstreamError{Service: "gitlab", Message: "unable to add spent time: " + strings.Join(result.TimelogCreate.Errors, ", ")}
+	}
+
+	return nil
+}
+
+func _gitlabProjectCachePath() string {
+	return _getHomeDir() + "/.timer/gitlab_projects"
+}
+
+// project ids already looked up on gitlab, by instance url and git remote url.
+func _readGitlabProjectCache() (map[string]int32, error) {
+	cache := map[string]int32{}
+
+	path := _gitlabProjectCachePath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, &StorageError{Path: path, Message: "unable to read gitlab project cache", Err: err}
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return cache, &StorageError{Path: path, Message: "malformed gitlab project cache", Err: err}
+	}
+
+	return cache, nil
+}
 
-		response, err := gitlabApiRequest("POST", fmt.Sprintf("/projects/%d/issues/%d/add_spent_time?duration=%ds&summary=%s", gitlabProject.Id, gitlabIssue.Iid, seconds, url.QueryEscape(summary)))
-		check(err)
+func _writeGitlabProjectCache(cache map[string]int32) error {
+	path := _gitlabProjectCachePath()
 
-		if response.StatusCode == 201 {
-			return true
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return &StorageError{Path: path, Message: "unable to write gitlab project cache", Err: err}
+	}
+
+	return nil
+}
+
+func _gitlabTrackerIssue(issue GitlabIssue) TrackerIssue {
+	return TrackerIssue{
+		Key:             fmt.Sprint(issue.Iid),
+		Project:         fmt.Sprint(issue.ProjectId),
+		Title:           issue.Title,
+		State:           issue.State,
+		Open:            issue.State == "opened",
+		EstimateSeconds: issue.TimeStats.TimeEstimate,
+		SpentSeconds:    issue.TimeStats.TotalTimeSpent,
+	}
+}
+
+// run a graphql query or mutation and decode its data into result.
+func (p *GitlabProvider) graphqlRequest(query string, variables map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", p.config.Url+"/api/graphql", bytes.NewReader(body))
+	if err != nil {
+		return &ConfigError{Message: "invalid gitlab url", Err: err}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := p.client.Do(req)
+	if err != nil {
+		return &UpstreamError{Service: "gitlab", Message: "unable to reach gitlab", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return &UpstreamError{Service: "gitlab", Message: "graphql request failed, please check your configuration", StatusCode: response.StatusCode}
+	}
+
+	var graphqlResponse struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&graphqlResponse); err != nil {
+		return &UpstreamError{Service: "gitlab", Message: "unexpected graphql response", Err: err}
+	}
+
+	if len(graphqlResponse.Errors) > 0 {
+		var messages []string
+		for _, graphqlError := range graphqlResponse.Errors {
+			messages = append(messages, graphqlError.Message)
 		}
 
-		fmt.Println("Warning: Unable to update gitlab, please check your configuration.")
+		return &UpstreamError{Service: "gitlab", Message: "graphql request failed: " + strings.Join(messages, ", ")}
+	}
 
-		return false
+	if err := json.Unmarshal(graphqlResponse.Data, result); err != nil {
+		return &UpstreamError{Service: "gitlab", Message: "unexpected graphql response", Err: err}
 	}
 
-	return false
+	return nil
 }
 
-func gitlabApiRequest(method string, path string) (*http.Response, error) {
-	req, err := http.NewRequest(method, config.GitlabServiceConfig.Url+"/api/v4"+path, nil)
+func (p *GitlabProvider) apiRequest(method string, path string) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.config.Url+"/api/v4"+path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("PRIVATE-TOKEN", config.GitlabServiceConfig.Token)
+	req.Header.Add("PRIVATE-TOKEN", p.config.Token)
 
-	return gitlabHttpClient.Do(req)
+	return p.client.Do(req)
 }
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var importFormats = []string{"csv", "json"}
+
+var importFields = []string{"id", "task", "start", "end", "seconds", "duration", "job_type", "status", "description"}
+
+var importTimeFormats = []string{time.RFC3339, "2006-01-02 15:04:05", entryTimeFormat, "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+/**
+ * Import
+ * Read entries from a csv or json file, validate them against the existing log and write them to their day files.
+ */
+func importEntries(path, format, columnMap string, dryRun bool) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	if !_contains(importFormats, format) {
+		check(&UsageError{Message: fmt.Sprintf("unknown import format %q, expected one of %v", format, importFormats)})
+	}
+
+	file, err := os.Open(path)
+	check(err)
+	defer file.Close()
+
+	var entries []LogEntry
+	var problems []string
+
+	switch format {
+	case "csv":
+		if columnMap == "" {
+			columnMap = config.import_csv_map
+		}
+
+		entries, problems = _readImportCsv(file, _parseColumnMap(columnMap))
+	case "json":
+		entries, problems = _readImportJson(file)
+	}
+
+	accepted, duplicates, importProblems := _checkImportEntries(entries)
+	problems = append(problems, importProblems...)
+
+	// nothing is written unless every entry is valid
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Println("Error:", problem)
+		}
+		fmt.Println(fmt.Sprintf("Nothing imported, %d problems found.", len(problems)))
+		os.Exit(1)
+	}
+
+	if dryRun {
+		for _, entry := range accepted {
+			fmt.Println(fmt.Sprintf("Would import %s %s %s %s", _entryDay(entry), entry.Task, _formatDuration(_entryDuration(entry)), entry.Description))
+		}
+		fmt.Println(fmt.Sprintf("%d entries would be imported, %d duplicates skipped.", len(accepted), duplicates))
+		os.Exit(0)
+	}
+
+	byDay := map[string][]LogEntry{}
+	for _, entry := range accepted {
+		byDay[_entryDay(entry)] = append(byDay[_entryDay(entry)], entry)
+	}
+
+	for day, dayEntries := range byDay {
+		existing, err := _readLogFile(day)
+		check(err)
+
+		check(_writeLogFile(day, append(existing, dayEntries...)))
+	}
+
+	fmt.Println(fmt.Sprintf("Imported %d entries, %d duplicates skipped.", len(accepted), duplicates))
+	os.Exit(0)
+}
+
+// entries that can be imported, skipping duplicates of logged or earlier imported entries and reporting overlaps.
+func _checkImportEntries(entries []LogEntry) ([]LogEntry, int, []string) {
+	var accepted []LogEntry
+	var duplicates int
+	var problems []string
+
+	for _, entry := range entries {
+		label := fmt.Sprintf("%s %s to %s", entry.Task, entry.Start.Local().Format(entryTimeFormat), entry.End.Local().Format(entryTimeFormat))
+
+		// zero length entries never overlap, so their duplicates are looked up in the day file
+		if _isLoggedEntry(entry) {
+			duplicates++
+			continue
+		}
+
+		if existing, found := _findOverlappingEntry(entry.Start, entry.End, ""); found {
+			if existing.Id == entry.Id || _isSameEntry(existing, entry) {
+				duplicates++
+				continue
+			}
+
+			problems = append(problems, fmt.Sprintf("%s overlaps logged %s %s to %s", label, existing.Task, existing.Start.Local().Format(entryTimeFormat), existing.End.Local().Format(entryTimeFormat)))
+			continue
+		}
+
+		isDuplicate := false
+
+		for _, other := range accepted {
+			if _isSameEntry(other, entry) {
+				isDuplicate = true
+				break
+			}
+
+			if other.Start.Before(entry.End) && entry.Start.Before(other.End) {
+				problems = append(problems, fmt.Sprintf("%s overlaps imported %s", label, other.Task))
+				break
+			}
+		}
+
+		if isDuplicate {
+			duplicates++
+			continue
+		}
+
+		if entry.Id == "" {
+			entry.Id = _newEntryId()
+		}
+
+		accepted = append(accepted, entry)
+	}
+
+	return accepted, duplicates, problems
+}
+
+func _isSameEntry(entry, other LogEntry) bool {
+	return entry.Task == other.Task && entry.Start.Equal(other.Start) && entry.End.Equal(other.End)
+}
+
+func _isLoggedEntry(entry LogEntry) bool {
+	entries, err := _readLogFile(_entryDay(entry))
+	check(err)
+
+	for _, existing := range entries {
+		if (entry.Id != "" && existing.Id == entry.Id) || _isSameEntry(existing, entry) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parse a field:column list such as task:Issue,start:Began into a field to column name map.
+func _parseColumnMap(columnMap string) map[string]string {
+	mapping := map[string]string{}
+
+	for _, field := range importFields {
+		mapping[field] = field
+	}
+
+	for _, pair := range strings.Split(columnMap, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+
+		if len(parts) != 2 || !_contains(importFields, strings.TrimSpace(parts[0])) {
+			check(&UsageError{Message: fmt.Sprintf("invalid column mapping %q, expected field:column with a field of %v", pair, importFields)})
+		}
+
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return mapping
+}
+
+func _readImportCsv(input io.Reader, mapping map[string]string) ([]LogEntry, []string) {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	check(err)
+
+	var entries []LogEntry
+	var problems []string
+
+	if len(rows) == 0 {
+		return entries, problems
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, field := range []string{"task", "start", "end"} {
+		if _, exists := columns[mapping[field]]; !exists {
+			problems = append(problems, fmt.Sprintf("missing column %s for %s", mapping[field], field))
+		}
+	}
+
+	if len(problems) > 0 {
+		return entries, problems
+	}
+
+	for line, row := range rows[1:] {
+		value := func(field string) string {
+			index, exists := columns[mapping[field]]
+			if !exists || index >= len(row) {
+				return ""
+			}
+
+			return strings.TrimSpace(row[index])
+		}
+
+		entry, problem := _importEntry(value)
+
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("row %d %s", line+2, problem))
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, problems
+}
+
+func _readImportJson(input io.Reader) ([]LogEntry, []string) {
+	var records []map[string]interface{}
+
+	err := json.NewDecoder(input).Decode(&records)
+	check(err)
+
+	var entries []LogEntry
+	var problems []string
+
+	for i, record := range records {
+		value := func(field string) string {
+			switch v := record[field].(type) {
+			case string:
+				return strings.TrimSpace(v)
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+
+			return ""
+		}
+
+		entry, problem := _importEntry(value)
+
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("entry %d %s", i+1, problem))
+			continue
+		}
+
+		// keep the sync state of entries exported from another timer install
+		if upstream, exists := record["upstream"].(map[string]interface{}); exists {
+			if state, _ := upstream["state"].(string); state != "" {
+				entry.Upstream.State = state
+				entry.Upstream.Service, _ = upstream["service"].(string)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, problems
+}
+
+// build and validate a log entry from an imported record's field values.
+func _importEntry(value func(field string) string) (LogEntry, string) {
+	var entry LogEntry
+
+	start, err := _parseImportTime(value("start"))
+	if err != nil {
+		return entry, fmt.Sprintf("has an invalid start time %q", value("start"))
+	}
+
+	end, err := _parseImportTime(value("end"))
+	if err != nil {
+		return entry, fmt.Sprintf("has an invalid end time %q", value("end"))
+	}
+
+	if value("task") == "" {
+		return entry, "has no task"
+	}
+
+	// day files are local days, whatever offset the source wrote
+	start, end = start.Local(), end.Local()
+
+	if end.Before(start) {
+		return entry, "ends before it starts"
+	}
+
+	if time.Now().Before(end) {
+		return entry, "ends in the future"
+	}
+
+	seconds := int64(end.Sub(start) / time.Second)
+
+	if value("seconds") != "" {
+		seconds, err = strconv.ParseInt(value("seconds"), 10, 64)
+		if err != nil {
+			return entry, fmt.Sprintf("has invalid seconds %q", value("seconds"))
+		}
+	} else if value("duration") != "" {
+		duration, err := _parseDuration(value("duration"))
+		if err != nil {
+			return entry, fmt.Sprintf("has an invalid duration %q", value("duration"))
+		}
+		seconds = int64(duration / time.Second)
+	}
+
+	if seconds < 0 || seconds > int64(end.Sub(start)/time.Second) {
+		return entry, "has a duration longer than its start to end time"
+	}
+
+	entry = _newLogEntry(value("task"), start, end, seconds, TaskDescription{
+		JobType:     value("job_type"),
+		Status:      value("status"),
+		Description: value("description"),
+	})
+	entry.Id = value("id")
+
+	return entry, ""
+}
+
+func _parseImportTime(value string) (time.Time, error) {
+	var err error
+
+	for _, format := range importTimeFormats {
+		var parsed time.Time
+
+		parsed, err = time.ParseInLocation(format, value, time.Local)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func _useLocation(t *testing.T, location *time.Location) {
+	local := time.Local
+	time.Local = location
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestImportEntryLocalDay(t *testing.T) {
+	_useLocation(t, time.FixedZone("AEST", 10*60*60))
+
+	entries, problems := _readImportCsv(strings.NewReader("task,start,end\nABC-1,2026-10-14T22:00:00Z,2026-10-14T23:00:00Z\n"), _parseColumnMap(""))
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+
+	if day := _entryDay(entries[0]); day != "2026-10-15" {
+		t.Errorf("imported into day %s, expected the local day 2026-10-15", day)
+	}
+
+	if entries[0].Seconds != 3600 {
+		t.Errorf("imported %d seconds, expected 3600", entries[0].Seconds)
+	}
+}
+
+func TestImportExportRoundTrip(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)
+	exported := []LogEntry{
+		_newLogEntry("ABC-1", start, start.Add(90*time.Minute), 5400, TaskDescription{JobType: "Dev", Status: "Billable", Description: "fixed, then tested"}),
+		// stopped straight after starting
+		_newLogEntry("ABC-2", start.Add(2*time.Hour), start.Add(2*time.Hour), 0, TaskDescription{}),
+	}
+
+	for format, read := range map[string]func(*bytes.Buffer) ([]LogEntry, []string){
+		"json": func(output *bytes.Buffer) ([]LogEntry, []string) {
+			_exportJson(output, exported)
+			return _readImportJson(output)
+		},
+		"csv": func(output *bytes.Buffer) ([]LogEntry, []string) {
+			_exportCsv(output, exported)
+			return _readImportCsv(output, _parseColumnMap(""))
+		},
+	} {
+		imported, problems := read(&bytes.Buffer{})
+		if len(problems) > 0 {
+			t.Fatalf("%s import problems %v", format, problems)
+		}
+
+		if len(imported) != len(exported) {
+			t.Fatalf("%s imported %d entries, expected %d", format, len(imported), len(exported))
+		}
+
+		for i, entry := range imported {
+			original := exported[i]
+
+			if entry.Id != original.Id || entry.Task != original.Task || entry.Seconds != original.Seconds || !entry.Start.Equal(original.Start) || !entry.End.Equal(original.End) || _entryTaskDescription(entry) != _entryTaskDescription(original) {
+				t.Errorf("%s imported %+v, expected %+v", format, entry, original)
+			}
+		}
+	}
+}
+
+func TestImportEntryEndsBeforeStart(t *testing.T) {
+	_, problems := _readImportJson(strings.NewReader(`[{"task":"ABC-1","start":"2026-10-16T10:00:00Z","end":"2026-10-16T09:00:00Z"}]`))
+
+	if len(problems) != 1 || problems[0] != "entry 1 ends before it starts" {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
+
+func TestCheckImportEntries(t *testing.T) {
+	_useTestHome(t)
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)
+	logged := _newLogEntry("ABC-1", start, start.Add(time.Hour), 3600, TaskDescription{})
+	stopped := _newLogEntry("ABC-2", start.Add(time.Hour), start.Add(time.Hour), 0, TaskDescription{})
+
+	if err := _writeLogFile("2026-10-16", []LogEntry{logged, stopped}); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := _newLogEntry("ABC-3", start.Add(2*time.Hour), start.Add(2*time.Hour), 0, TaskDescription{})
+	fresh.Id = ""
+
+	accepted, duplicates, problems := _checkImportEntries([]LogEntry{
+		logged,
+		stopped,
+		fresh,
+		fresh,
+		_newLogEntry("ABC-4", start.Add(30*time.Minute), start.Add(90*time.Minute), 3600, TaskDescription{}),
+	})
+
+	if len(accepted) != 1 || accepted[0].Task != "ABC-3" || accepted[0].Id == "" {
+		t.Errorf("unexpected accepted entries %+v", accepted)
+	}
+
+	if duplicates != 3 {
+		t.Errorf("skipped %d duplicates, expected 3", duplicates)
+	}
+
+	if len(problems) != 1 || !strings.Contains(problems[0], "ABC-4 2026-10-16 09:30 to 2026-10-16 10:30 overlaps logged ABC-1") {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// the upstream issue of the started task, resolved on start so status and ps1 don't need the network.
+type CachedIssue struct {
+	Task     string       `json:"task"`
+	Service  string       `json:"service"`
+	Issue    TrackerIssue `json:"issue"`
+	CachedAt time.Time    `json:"cached_at"`
+}
+
+func _issueCachePath() string {
+	return _getHomeDir() + "/.timer/issue"
+}
+
+// resolve the upstream issue of a task being started, exits when it is closed.
+// false when the task has no upstream or the issue can't be loaded.
+func _resolveStartIssue(task, profile string) (CachedIssue, bool) {
+	provider, found := _providerForTask(task, profile)
+	if !found {
+		return CachedIssue{}, false
+	}
+
+	issue, err := provider.ResolveIssue(task)
+
+	if err != nil {
+		// task names can look like issue keys, and offline or a flaky upstream shouldn't stop time tracking
+		fmt.Println(fmt.Sprintf("Warning: unable to load %s issue %s, it is checked again on stop.", provider.Name(), task))
+		_printUpstreamError(err)
+
+		return CachedIssue{}, false
+	}
+
+	if !issue.Open {
+		check(&UsageError{Message: fmt.Sprintf("%s issue %s is %s", provider.Name(), task, issue.State)})
+	}
+
+	return CachedIssue{Task: task, Service: provider.Name(), Issue: issue, CachedAt: time.Now()}, true
+}
+
+func _writeIssueCache(cached CachedIssue) error {
+	path := _issueCachePath()
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return &StorageError{Path: path, Message: "unable to write issue cache", Err: err}
+	}
+
+	return nil
+}
+
+// the cached issue of a task, false when there is none or it belongs to another task.
+func _readIssueCache(task string) (CachedIssue, bool) {
+	var cached CachedIssue
+
+	data, err := os.ReadFile(_issueCachePath())
+	if err != nil {
+		return cached, false
+	}
+
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Task != task {
+		return cached, false
+	}
+
+	return cached, true
+}
+
+func _removeIssueCache() error {
+	path := _issueCachePath()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return &StorageError{Path: path, Message: "unable to remove issue cache", Err: err}
+	}
+
+	return nil
+}
+
+// task, title and time spent including the running timer, e.g. PROJ-12 Fix login (3h of 5h).
+func _issueSummary(cached CachedIssue, elapsed time.Duration, maxTitle int) string {
+	summary := cached.Task
+	title := cached.Issue.Title
+
+	if title != "" && title != cached.Task {
+		if maxTitle > 0 && len([]rune(title)) > maxTitle {
+			title = string([]rune(title)[:maxTitle-1]) + "…"
+		}
+
+		summary += " " + title
+	}
+
+	spent := cached.Issue.SpentSeconds + int64(elapsed/time.Second)
+
+	if cached.Issue.EstimateSeconds > 0 {
+		summary += fmt.Sprintf(" (%s of %s)", _formatHours(spent), _formatHours(cached.Issue.EstimateSeconds))
+	} else if cached.Issue.SpentSeconds > 0 {
+		summary += fmt.Sprintf(" (%s spent)", _formatHours(spent))
+	}
+
+	return summary
+}
+
+// seconds over the issue estimate including the running timer, 0 when within or without an estimate.
+func _overEstimateSeconds(cached CachedIssue, elapsed time.Duration) int64 {
+	if cached.Issue.EstimateSeconds == 0 {
+		return 0
+	}
+
+	over := cached.Issue.SpentSeconds + int64(elapsed/time.Second) - cached.Issue.EstimateSeconds
+	if over < 0 {
+		return 0
+	}
+
+	return over
+}
+
+// whole hours and minutes such as 3h, 45m or 1h 30m.
+func _formatHours(seconds int64) string {
+	minutes := seconds / 60
+
+	switch {
+	case minutes%60 == 0 && minutes > 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
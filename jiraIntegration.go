@@ -7,14 +7,34 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 )
 
 type JiraConfig struct {
 	Url      string
 	Username string
 	Token    string
+	// rest api version 2 or 3, 3 uses atlassian document format for rich text
+	ApiVersion string
+	// basic (username and api token), bearer (personal access token), oauth1 or oauth2
+	Auth string
+	// oauth 2.0 app credentials, the refresh token from the config is only used until a rotated one is stored
+	ClientId     string
+	ClientSecret string
+	RefreshToken string
+	TokenUrl     string
+	// oauth 1.0a application link consumer, rsa private key file and access token
+	ConsumerKey    string
+	PrivateKeyPath string
+	AccessToken    string
 }
 
+// worklog started timestamps, jira rejects RFC3339
+const jiraTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+var jiraApiPath = regexp.MustCompile(`/rest/api/([0-9]+|latest)/?$`)
+
 type JiraIssue struct {
 	Key    string          `json:"key"`
 	Fields JiraIssueFields `json:"fields"`
@@ -22,104 +42,330 @@ type JiraIssue struct {
 
 type JiraIssueFields struct {
 	Summary      string                `json:"summary"`
-	Description  string                `json:"description"`
+	Description  JiraRichText          `json:"description"`
 	Created      string                `json:"created"`
+	Status       JiraIssueStatus       `json:"status"`
 	TimeTracking JiraIssueTimeTracking `json:"timetracking"`
 }
 
+type JiraIssueStatus struct {
+	Name           string                  `json:"name"`
+	StatusCategory JiraIssueStatusCategory `json:"statusCategory"`
+}
+
+type JiraIssueStatusCategory struct {
+	Key string `json:"key"`
+}
+
 type JiraIssueTimeTracking struct {
 	OriginalEstimateSeconds  int `json:"originalEstimateSeconds"`
 	RemainingEstimateSeconds int `json:"remainingEstimateSeconds"`
 	TimeSpentSeconds         int `json:"timeSpentSeconds"`
 }
 
-var jiraHttpClient *http.Client
-var jiraCurrentTask JiraIssue
+type JiraProvider struct {
+	config JiraConfig
+	client *http.Client
+}
+
+func init() {
+	_registerProvider("jira", func(settings map[string]string) TrackerProvider {
+		auth := settings["auth"]
+		if auth == "" {
+			auth = "basic"
+		}
+
+		tokenUrl := settings["token_url"]
+		if tokenUrl == "" {
+			tokenUrl = "https://auth.atlassian.com/oauth/token"
+		}
+
+		provider := &JiraProvider{
+			config: JiraConfig{
+				Url:            strings.TrimSuffix(settings["url"], "/"),
+				Username:       settings["username"],
+				Token:          settings["token"],
+				ApiVersion:     settings["api_version"],
+				Auth:           auth,
+				ClientId:       settings["client_id"],
+				ClientSecret:   settings["client_secret"],
+				RefreshToken:   settings["refresh_token"],
+				TokenUrl:       tokenUrl,
+				ConsumerKey:    settings["consumer_key"],
+				PrivateKeyPath: settings["private_key"],
+				AccessToken:    settings["access_token"],
+			},
+		}
 
-func _checkAndLoadJiraIssue(taskKey string) bool {
-	if _configIsComplete() {
-		if jiraHttpClient == nil {
-			jiraHttpClient = &http.Client{
-				CheckRedirect: func(req *http.Request, via []*http.Request) error {
-					auth := base64.StdEncoding.EncodeToString([]byte(config.JiraServiceConfig.Username + ":" + config.JiraServiceConfig.Token))
-					req.Header.Add("Authorization", "Basic "+auth)
+		provider.client = &http.Client{
+			Timeout: 10 * time.Second,
+			// oauth signatures cover the url, so redirects within the site are authorized again.
+			// credentials are never sent to another host.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
 
+				if req.URL.Host != via[0].URL.Host {
+					req.Header.Del("Authorization")
 					return nil
-				},
-			}
+				}
+
+				return provider.authorize(req)
+			},
 		}
 
-		req, err := http.NewRequest("GET", config.JiraServiceConfig.Url+"/issue/"+taskKey, nil)
-		check(err)
+		return provider
+	})
+}
+
+func isJiraTaskFormat(identifier string) bool {
+	taskFmt := regexp.MustCompile(`(?i)[A-Z0-9]+-[0-9]+`)
+
+	return taskFmt.Match([]byte(identifier))
+}
 
-		auth := base64.StdEncoding.EncodeToString([]byte(config.JiraServiceConfig.Username + ":" + config.JiraServiceConfig.Token))
-		req.Header.Add("Authorization", "Basic "+auth)
+func (p *JiraProvider) Name() string {
+	return "jira"
+}
 
-		response, err := jiraHttpClient.Do(req)
-		check(err)
+func (p *JiraProvider) MatchesTask(task string) bool {
+	return isJiraTaskFormat(task)
+}
 
-		defer response.Body.Close()
+// the rest api base of the site url, urls already ending in /rest/api/<version> are used as they are.
+func (p *JiraProvider) apiBase() string {
+	if jiraApiPath.MatchString(p.config.Url) {
+		return p.config.Url
+	}
 
-		if response.StatusCode == 200 {
-			json.NewDecoder(response.Body).Decode(&jiraCurrentTask)
-		} else if response.StatusCode == 401 {
-			fmt.Println("Warning: Unable to update Jira, please check your configuration.")
+	return p.config.Url + "/rest/api/" + p.apiVersion()
+}
+
+func (p *JiraProvider) apiVersion() string {
+	if match := jiraApiPath.FindStringSubmatch(p.config.Url); match != nil {
+		return match[1]
+	}
+
+	if p.config.ApiVersion == "" {
+		return "2"
+	}
+
+	return p.config.ApiVersion
+}
+
+// the settings missing for the auth scheme, nil when the config is complete.
+func (p *JiraProvider) configError() error {
+	if p.config.Url == "" {
+		return &ConfigError{Message: "jira requires url in the config"}
+	}
+
+	switch p.config.Auth {
+	case "basic":
+		if p.config.Username == "" || p.config.Token == "" {
+			return &ConfigError{Message: "jira basic auth requires username and token in the config"}
+		}
+	case "bearer":
+		if p.config.Token == "" {
+			return &ConfigError{Message: "jira bearer auth requires a personal access token as token in the config"}
+		}
+	case "oauth2":
+		if p.config.ClientId == "" || p.config.ClientSecret == "" {
+			return &ConfigError{Message: "jira oauth2 auth requires client_id and client_secret in the config"}
 		}
+	case "oauth1":
+		if p.config.ConsumerKey == "" || p.config.PrivateKeyPath == "" || p.config.AccessToken == "" {
+			return &ConfigError{Message: "jira oauth1 auth requires consumer_key, private_key and access_token in the config"}
+		}
+	default:
+		return &ConfigError{Message: fmt.Sprintf("unknown jira auth %q, expected one of basic, bearer, oauth1 or oauth2", p.config.Auth)}
+	}
+
+	return nil
+}
 
-		return response.StatusCode == 200
-	} else {
-		fmt.Println("Warning: Unable to update Jira, please check your configuration.")
+func (p *JiraProvider) ResolveIssue(taskKey string) (TrackerIssue, error) {
+	if err := p.configError(); err != nil {
+		return TrackerIssue{}, err
+	}
 
-		return false
+	response, err := p.apiRequest("GET", "/issue/"+taskKey, nil)
+	if err != nil {
+		return TrackerIssue{}, err
 	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case 200:
+		var issue JiraIssue
+		if err := json.NewDecoder(response.Body).Decode(&issue); err != nil {
+			return TrackerIssue{}, &UpstreamError{Service: "jira", Message: "unexpected issue response", Err: err}
+		}
+
+		return _jiraTrackerIssue(issue), nil
+	case 401, 403:
+		return TrackerIssue{}, &UpstreamError{Service: "jira", Message: "unauthorized, please check your configuration", StatusCode: response.StatusCode}
+	}
+
+	return TrackerIssue{}, &UpstreamError{Service: "jira", Message: "issue " + taskKey + " not found", StatusCode: response.StatusCode}
 }
 
-func _submitJiraWorkLog(taskKey string, info TaskDescription, seconds int64) bool {
-	if _configIsComplete() {
-		if jiraHttpClient == nil {
-			jiraHttpClient = &http.Client{
-				CheckRedirect: func(req *http.Request, via []*http.Request) error {
-					auth := base64.StdEncoding.EncodeToString([]byte(config.JiraServiceConfig.Username + ":" + config.JiraServiceConfig.Token))
-					req.Header.Add("Authorization", "Basic "+auth)
+func (p *JiraProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	if err := p.configError(); err != nil {
+		return err
+	}
 
-					return nil
-				},
-			}
+	var comment interface{} = _summaryComment(workLog.Info)
+	if p.apiVersion() == "3" {
+		comment = _jiraDocument(_summaryComment(workLog.Info))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"comment":          comment,
+		"started":          workLog.Start.Format(jiraTimeFormat),
+		"timeSpentSeconds": workLog.Seconds,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := p.apiRequest("POST", "/issue/"+issue.Key+"/worklog", body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == 201 {
+		return nil
+	}
+
+	return &UpstreamError{Service: "jira", Message: fmt.Sprintf("unable to create worklog on %s", issue.Key), StatusCode: response.StatusCode}
+}
+
+func (p *JiraProvider) apiRequest(method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.apiBase()+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, &ConfigError{Message: "invalid jira url", Err: err}
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if err := p.authorize(req); err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.Do(req)
+	if err != nil {
+		return nil, &UpstreamError{Service: "jira", Message: "unable to reach jira", Err: err}
+	}
+
+	return response, nil
+}
+
+// set the authorization header of the configured auth scheme.
+func (p *JiraProvider) authorize(req *http.Request) error {
+	switch p.config.Auth {
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	case "oauth2":
+		accessToken, err := p.oauth2AccessToken()
+		if err != nil {
+			return err
 		}
 
-		var summary string
-		if config.billable_enable {
-			summary = fmt.Sprintf("Job Type: %s\nStatus: %s\nDescription: %s", info.JobType, info.Status, info.Description)
-		} else {
-			summary = fmt.Sprintf("Job Type: %s\nDescription: %s", info.JobType, info.Description)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	case "oauth1":
+		authorization, err := p.oauth1Authorization(req)
+		if err != nil {
+			return err
 		}
 
-		body, err := json.Marshal(map[string]interface{}{
-			"comment":          summary,
-			"timeSpentSeconds": seconds,
-		})
-		check(err)
+		req.Header.Set("Authorization", authorization)
+	default:
+		auth := base64.StdEncoding.EncodeToString([]byte(p.config.Username + ":" + p.config.Token))
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	return nil
+}
 
-		req, err := http.NewRequest("POST", config.JiraServiceConfig.Url+"/issue/"+taskKey+"/worklog", bytes.NewBuffer(body))
-		req.Header.Set("Content-type", "application/json")
-		check(err)
+func _jiraTrackerIssue(issue JiraIssue) TrackerIssue {
+	return TrackerIssue{
+		Key:             issue.Key,
+		Title:           issue.Fields.Summary,
+		State:           issue.Fields.Status.Name,
+		Open:            issue.Fields.Status.StatusCategory.Key != "done",
+		EstimateSeconds: int64(issue.Fields.TimeTracking.OriginalEstimateSeconds),
+		SpentSeconds:    int64(issue.Fields.TimeTracking.TimeSpentSeconds),
+	}
+}
 
-		auth := base64.StdEncoding.EncodeToString([]byte(config.JiraServiceConfig.Username + ":" + config.JiraServiceConfig.Token))
-		req.Header.Add("Authorization", "Basic "+auth)
+// plain text of a rich text field, a string in api version 2 and an atlassian document in version 3.
+type JiraRichText string
 
-		response, err := jiraHttpClient.Do(req)
-		check(err)
+type JiraDocumentNode struct {
+	Type    string             `json:"type"`
+	Text    string             `json:"text,omitempty"`
+	Version int                `json:"version,omitempty"`
+	Content []JiraDocumentNode `json:"content,omitempty"`
+}
 
-		defer response.Body.Close()
+func (t *JiraRichText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = JiraRichText(text)
+		return nil
+	}
 
-		return response.StatusCode == 201
+	var document JiraDocumentNode
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
 	}
 
-	return false
+	*t = JiraRichText(strings.TrimSpace(_jiraDocumentText(document)))
+
+	return nil
 }
 
-func isJiraTaskFormat(identifier string) bool {
-	taskFmt := regexp.MustCompile(`(?i)[A-Z0-9]+-[0-9]+`)
+func _jiraDocumentText(node JiraDocumentNode) string {
+	switch node.Type {
+	case "text":
+		return node.Text
+	case "hardBreak":
+		return "\n"
+	}
 
-	return taskFmt.Match([]byte(identifier))
+	var builder strings.Builder
+
+	for _, child := range node.Content {
+		builder.WriteString(_jiraDocumentText(child))
+	}
+
+	// block nodes end a line
+	switch node.Type {
+	case "paragraph", "heading", "codeBlock", "listItem", "blockquote", "rule":
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// an atlassian document with a paragraph per line of text.
+func _jiraDocument(text string) JiraDocumentNode {
+	document := JiraDocumentNode{Type: "doc", Version: 1, Content: []JiraDocumentNode{}}
+
+	for _, line := range strings.Split(text, "\n") {
+		paragraph := JiraDocumentNode{Type: "paragraph"}
+
+		// empty text nodes are rejected
+		if line != "" {
+			paragraph.Content = []JiraDocumentNode{{Type: "text", Text: line}}
+		}
+
+		document.Content = append(document.Content, paragraph)
+	}
+
+	return document
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJiraDocumentEncode(t *testing.T) {
+	encoded, err := json.Marshal(_jiraDocument("Job Type: Dev\n\nDescription: fixed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"type":"doc","version":1,"content":[` +
+		`{"type":"paragraph","content":[{"type":"text","text":"Job Type: Dev"}]},` +
+		`{"type":"paragraph"},` +
+		`{"type":"paragraph","content":[{"type":"text","text":"Description: fixed"}]}]}`
+
+	if string(encoded) != expected {
+		t.Errorf("encoded %s, expected %s", encoded, expected)
+	}
+}
+
+func TestJiraRichTextDecode(t *testing.T) {
+	for input, expected := range map[string]string{
+		// api version 2
+		`"plain text"`: "plain text",
+		`null`:         "",
+		// api version 3
+		`{"type":"doc","version":1,"content":[
+			{"type":"heading","content":[{"type":"text","text":"Steps"}]},
+			{"type":"paragraph","content":[{"type":"text","text":"open "},{"type":"text","text":"login","marks":[{"type":"strong"}]},{"type":"hardBreak"},{"type":"text","text":"submit"}]},
+			{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"item"}]}]}]}
+		]}`: "Steps\nopen login\nsubmit\nitem",
+	} {
+		var text JiraRichText
+
+		if err := json.Unmarshal([]byte(input), &text); err != nil {
+			t.Fatal(err)
+		}
+
+		if string(text) != expected {
+			t.Errorf("decoded %q, expected %q", text, expected)
+		}
+	}
+}
+
+func TestJiraDocumentRoundTrip(t *testing.T) {
+	encoded, err := json.Marshal(_jiraDocument("Job Type: Dev\nDescription: fixed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text JiraRichText
+	if err := json.Unmarshal(encoded, &text); err != nil {
+		t.Fatal(err)
+	}
+
+	if text != "Job Type: Dev\nDescription: fixed" {
+		t.Errorf("round trip gave %q", text)
+	}
+}
+
+func TestJiraApiBase(t *testing.T) {
+	for _, test := range []struct {
+		url, version, expected string
+	}{
+		{"https://example.atlassian.net", "", "https://example.atlassian.net/rest/api/2"},
+		{"https://example.atlassian.net/", "3", "https://example.atlassian.net/rest/api/3"},
+		{"https://jira.example.com/rest/api/2", "3", "https://jira.example.com/rest/api/2"},
+	} {
+		provider := trackerProviders["jira"](map[string]string{"url": test.url, "api_version": test.version}).(*JiraProvider)
+
+		if base := provider.apiBase(); base != test.expected {
+			t.Errorf("apiBase of %s version %q = %s, expected %s", test.url, test.version, base, test.expected)
+		}
+	}
+}
+
+func TestJiraRedirectAuthorization(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("credentials sent to another host %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"key":"ABC-1"}`))
+	}))
+	t.Cleanup(foreign.Close)
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected authorization %q on %s", r.Header.Get("Authorization"), r.URL.Path)
+		}
+
+		switch r.URL.Path {
+		case "/rest/api/2/issue/ABC-1":
+			http.Redirect(w, r, "/rest/api/2/issue/ABC-2", http.StatusFound)
+		case "/rest/api/2/issue/ABC-2":
+			http.Redirect(w, r, foreign.URL+"/issue", http.StatusFound)
+		default:
+			http.Redirect(w, r, r.URL.Path, http.StatusFound)
+		}
+	}))
+	t.Cleanup(site.Close)
+
+	provider := trackerProviders["jira"](map[string]string{"url": site.URL, "token": "secret", "auth": "bearer"})
+
+	if _, err := provider.ResolveIssue("ABC-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a redirect loop ends
+	if _, err := provider.ResolveIssue("ABC-3"); err == nil {
+		t.Error("expected an error for a redirect loop")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// oauth 2.0 tokens of a jira app, refresh tokens rotate so the latest is kept locally.
+type JiraOAuthToken struct {
+	RefreshToken string    `json:"refresh_token"`
+	AccessToken  string    `json:"access_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func _jiraOAuthTokensPath() string {
+	return _getHomeDir() + "/.timer/jira_oauth"
+}
+
+// stored tokens by oauth client id.
+func _readJiraOAuthTokens() (map[string]JiraOAuthToken, error) {
+	tokens := map[string]JiraOAuthToken{}
+
+	path := _jiraOAuthTokensPath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return tokens, &StorageError{Path: path, Message: "unable to read jira oauth tokens", Err: err}
+	}
+
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return tokens, &StorageError{Path: path, Message: "malformed jira oauth tokens", Err: err}
+	}
+
+	return tokens, nil
+}
+
+func _writeJiraOAuthTokens(tokens map[string]JiraOAuthToken) error {
+	path := _jiraOAuthTokensPath()
+
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	// tokens grant access to jira, keep them private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return &StorageError{Path: path, Message: "unable to write jira oauth tokens", Err: err}
+	}
+
+	return nil
+}
+
+// a valid access token, refreshed with the stored or configured refresh token when expired.
+func (p *JiraProvider) oauth2AccessToken() (string, error) {
+	tokens, err := _readJiraOAuthTokens()
+	if err != nil {
+		return "", err
+	}
+
+	stored := tokens[p.config.ClientId]
+
+	if stored.AccessToken != "" && time.Now().Add(time.Minute).Before(stored.ExpiresAt) {
+		return stored.AccessToken, nil
+	}
+
+	refreshToken := stored.RefreshToken
+	if refreshToken == "" {
+		refreshToken = p.config.RefreshToken
+	}
+
+	if refreshToken == "" {
+		return "", &ConfigError{Message: "jira oauth2 auth requires refresh_token in the config"}
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {p.config.ClientId},
+		"client_secret": {p.config.ClientSecret},
+		"refresh_token": {refreshToken},
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	response, err := client.PostForm(p.config.TokenUrl, form)
+	if err != nil {
+		return "", &UpstreamError{Service: "jira", Message: "unable to reach the oauth token url", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return "", &UpstreamError{Service: "jira", Message: "unable to refresh the oauth access token, a new refresh_token may be required", StatusCode: response.StatusCode}
+	}
+
+	var refreshed struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&refreshed); err != nil {
+		return "", &UpstreamError{Service: "jira", Message: "unexpected oauth token response", Err: err}
+	}
+
+	stored.AccessToken = refreshed.AccessToken
+	stored.ExpiresAt = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second)
+	stored.RefreshToken = refreshToken
+	if refreshed.RefreshToken != "" {
+		stored.RefreshToken = refreshed.RefreshToken
+	}
+
+	tokens[p.config.ClientId] = stored
+	if err := _writeJiraOAuthTokens(tokens); err != nil {
+		return "", err
+	}
+
+	return stored.AccessToken, nil
+}
+
+// RSA-SHA1 signed oauth 1.0a authorization header of a request, as used by jira application links.
+func (p *JiraProvider) oauth1Authorization(req *http.Request) (string, error) {
+	privateKey, err := _readRsaPrivateKey(_expandHome(p.config.PrivateKeyPath))
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     p.config.ConsumerKey,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        fmt.Sprint(time.Now().Unix()),
+		"oauth_token":            p.config.AccessToken,
+		"oauth_version":          "1.0",
+	}
+
+	// the signature covers the oauth and query parameters sorted by name then value
+	var params []string
+	for key, value := range oauthParams {
+		params = append(params, _oauthEscape(key)+"="+_oauthEscape(value))
+	}
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			params = append(params, _oauthEscape(key)+"="+_oauthEscape(value))
+		}
+	}
+	sort.Strings(params)
+
+	baseUrl := req.URL.Scheme + "://" + strings.ToLower(req.URL.Host) + req.URL.EscapedPath()
+	baseString := strings.Join([]string{req.Method, _oauthEscape(baseUrl), _oauthEscape(strings.Join(params, "&"))}, "&")
+
+	hash := sha1.Sum([]byte(baseString))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA1, hash[:])
+	if err != nil {
+		return "", err
+	}
+	oauthParams["oauth_signature"] = base64.StdEncoding.EncodeToString(signature)
+
+	var header []string
+	for key, value := range oauthParams {
+		header = append(header, fmt.Sprintf("%s=\"%s\"", key, _oauthEscape(value)))
+	}
+	sort.Strings(header)
+
+	return "OAuth " + strings.Join(header, ", "), nil
+}
+
+// rfc 3986 percent encoding required by oauth 1.0a.
+func _oauthEscape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func _readRsaPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Message: "unable to read jira private_key " + path, Err: err}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, &ConfigError{Message: "jira private_key " + path + " is not a pem file"}
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, &ConfigError{Message: "invalid jira private_key " + path, Err: err}
+	}
+
+	rsaKey, isRsa := key.(*rsa.PrivateKey)
+	if !isRsa {
+		return nil, &ConfigError{Message: "jira private_key " + path + " is not an rsa key"}
+	}
+
+	return rsaKey, nil
+}
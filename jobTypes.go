@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+const jobTypeOther = "Other…"
+
+var defaultJobTypes = []string{
+	"Frontend Development",
+	"Code Review",
+	"Deployment",
+	"Internal Meeting",
+	"Backend Development",
+	"Design",
+	"Client Meeting",
+	"Quality Assurance",
+	"Project Discovery",
+	"Project Management",
+	"Strategy",
+	"Site Analysis",
+	"Research",
+}
+
+// config key and job types for a task, most specific first: job_types.<task prefix>, job_types.<upstream_service>, job_types.
+func _jobTypesFor(task string) (string, []string) {
+	keys := []string{}
+
+	if prefix := strings.SplitN(task, "-", 2)[0]; prefix != "" && prefix != task {
+		keys = append(keys, "job_types."+prefix)
+	}
+
+	if _, profile := _routeTaskHere(task); _profileIndex(profile) != -1 {
+		keys = append(keys, "job_types."+config.Profiles[_profileIndex(profile)].Service)
+	}
+
+	keys = append(keys, "job_types")
+
+	for _, key := range keys {
+		if types, exists := config.job_types[key]; exists && len(types) > 0 {
+			return key, types
+		}
+	}
+
+	return "job_types", defaultJobTypes
+}
+
+// days of log files searched for the last job type of a task
+const lastJobTypeDays = 90
+
+// job type of the most recently logged entry for the task, searching the newest day files first.
+// only a default for the prompt, so unreadable files are skipped.
+func _lastJobType(task string) string {
+	days, err := _listLogDays()
+	if err != nil {
+		return ""
+	}
+
+	oldest := time.Now().AddDate(0, 0, -lastJobTypeDays).Format("2006-01-02")
+
+	for i := len(days) - 1; i >= 0 && days[i] >= oldest; i-- {
+		entries, err := _readLogFile(days[i])
+		if err != nil {
+			continue
+		}
+
+		for j := len(entries) - 1; j >= 0; j-- {
+			if entries[j].Task == task && entries[j].JobType != "" {
+				return entries[j].JobType
+			}
+		}
+	}
+
+	return ""
+}
+
+// job type select options for a task and the default, the current value is kept as an option even when no longer configured.
+func _jobTypeOptions(task, current string) ([]string, string) {
+	_, types := _jobTypesFor(task)
+	options := append([]string{}, types...)
+
+	if current != "" && !_contains(options, current) {
+		options = append([]string{current}, options...)
+	}
+
+	if config.job_types_other {
+		options = append(options, jobTypeOther)
+	}
+
+	defaultType := current
+	if defaultType == "" {
+		defaultType = _lastJobType(task)
+	}
+	if !_contains(options, defaultType) {
+		defaultType = options[0]
+	}
+
+	return options, defaultType
+}
+
+// when Other… was picked ask for the new job type and save it to the config list used for the task.
+func _resolveJobType(task, jobType string) string {
+	if jobType != jobTypeOther {
+		return jobType
+	}
+
+	var newType string
+	err := survey.AskOne(&survey.Input{Message: "New JobType:"}, &newType, survey.WithValidator(func(value interface{}) error {
+		text := strings.TrimSpace(value.(string))
+
+		if text == "" || text == jobTypeOther || strings.Contains(text, ",") {
+			return fmt.Errorf("a job type is required and may not contain a comma")
+		}
+
+		return nil
+	}))
+	check(err)
+
+	newType = strings.TrimSpace(newType)
+	key, types := _jobTypesFor(task)
+
+	if !_contains(types, newType) {
+		types = append(append([]string{}, types...), newType)
+		config.job_types[key] = types
+
+		_saveConfigValue(key, strings.Join(types, ","))
+		fmt.Println(fmt.Sprintf("Saved %s to %s.", newType, key))
+	}
+
+	return newType
+}
+
+// replace a key=value line in the config file or append it.
+func _saveConfigValue(key, value string) {
+	path := _getHomeDir() + "/.timer/config"
+	data, err := os.ReadFile(path)
+	check(err)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	found := false
+	// global settings must come before the first [profile] section
+	firstSection := len(lines)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			firstSection = i
+			break
+		}
+
+		if strings.SplitN(trimmed, "=", 2)[0] == key {
+			lines[i] = key + "=" + value
+			found = true
+		}
+	}
+
+	if !found {
+		lines = append(lines[:firstSection], append([]string{key + "=" + value}, lines[firstSection:]...)...)
+	}
+
+	err = os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	check(err)
+}
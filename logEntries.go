@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// version 0 is the legacy comma separated row: task,duration,start,end,base64(description)
+const logFormatVersion = 1
+
+const (
+	syncStateNone     = "none"     // no upstream configured or task is not an upstream identifier
+	syncStateSynced   = "synced"   // worklog was created upstream
+	syncStateFailed   = "failed"   // worklog submission failed
+	syncStateRejected = "rejected" // the upstream refused the worklog or issue, retrying won't help
+	syncStateUnknown  = "unknown"  // migrated from the legacy format, which did not record sync results
+)
+
+type UpstreamSync struct {
+	Service  string     `json:"service,omitempty"`
+	State    string     `json:"state"`
+	SyncedAt *time.Time `json:"synced_at,omitempty"`
+}
+
+type LogEntry struct {
+	Version     int          `json:"version"`
+	Id          string       `json:"id"`
+	Task        string       `json:"task"`
+	Seconds     int64        `json:"seconds"`
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	JobType     string       `json:"job_type"`
+	Status      string       `json:"status"`
+	Description string       `json:"description"`
+	Profile     string       `json:"profile,omitempty"`
+	Upstream    UpstreamSync `json:"upstream"`
+}
+
+func _newEntryId() string {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	check(err)
+
+	return hex.EncodeToString(id)
+}
+
+// legacy rows have no id, derive a stable one from the row so updates before migrating find it again.
+func _legacyEntryId(line string) string {
+	hash := sha256.Sum256([]byte(line))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+func _newLogEntry(task string, start, end time.Time, seconds int64, info TaskDescription) LogEntry {
+	return LogEntry{
+		Version:     logFormatVersion,
+		Id:          _newEntryId(),
+		Task:        task,
+		Seconds:     seconds,
+		Start:       start,
+		End:         end,
+		JobType:     info.JobType,
+		Status:      info.Status,
+		Description: info.Description,
+		Upstream:    UpstreamSync{State: syncStateNone},
+	}
+}
+
+func _entryTaskDescription(entry LogEntry) TaskDescription {
+	return TaskDescription{JobType: entry.JobType, Status: entry.Status, Description: entry.Description}
+}
+
+func _entryDuration(entry LogEntry) time.Duration {
+	return time.Duration(entry.Seconds) * time.Second
+}
+
+func _entryDay(entry LogEntry) string {
+	return entry.Start.Format("2006-01-02")
+}
+
+func _logFilePath(day string) string {
+	return _getHomeDir() + "/.timer/logs/" + day
+}
+
+// parse a single day file line in either the legacy or current format.
+func _parseLogLine(line string) (LogEntry, error) {
+	var entry LogEntry
+
+	if strings.HasPrefix(line, "{") {
+		err := json.Unmarshal([]byte(line), &entry)
+
+		return entry, err
+	}
+
+	fields := strings.Split(line, ",")
+
+	if len(fields) < 5 {
+		return entry, fmt.Errorf("expected 5 fields, found %d", len(fields))
+	}
+
+	// tasks weren't escaped, anything before the last four fields is the task
+	task := strings.Join(fields[:len(fields)-4], ",")
+	fields = append([]string{task}, fields[len(fields)-4:]...)
+
+	entry.Version = logFormatVersion
+	entry.Id = _legacyEntryId(line)
+	entry.Task = fields[0]
+	entry.Upstream = UpstreamSync{State: syncStateUnknown}
+
+	var err error
+	entry.Start, err = time.Parse(time.RFC3339, fields[2])
+	if err != nil {
+		return entry, err
+	}
+
+	entry.End, err = time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return entry, err
+	}
+
+	duration, err := _parseDuration(fields[1])
+	if err != nil {
+		return entry, err
+	}
+	entry.Seconds = int64(duration / time.Second)
+
+	description, err := base64.StdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return entry, err
+	}
+	entry.Description = string(description)
+
+	return entry, nil
+}
+
+func _readLogFile(day string) ([]LogEntry, error) {
+	var entries []LogEntry
+
+	if !_logFileExists(day) {
+		return entries, nil
+	}
+
+	path := _logFilePath(day)
+	file, err := os.Open(path)
+	if err != nil {
+		return entries, &StorageError{Path: path, Message: "unable to read log file", Err: err}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		entry, err := _parseLogLine(line)
+		if err != nil {
+			return entries, &StorageError{Path: fmt.Sprintf("%s:%d", path, lineNumber), Message: "malformed log entry", Err: err}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, &StorageError{Path: path, Message: "unable to read log file", Err: err}
+	}
+
+	return entries, nil
+}
+
+// rewrite a day file with the given entries ordered by start time, removes the file when empty.
+func _writeLogFile(day string, entries []LogEntry) error {
+	path := _logFilePath(day)
+
+	if len(entries) == 0 {
+		if _logFileExists(day) {
+			if err := os.Remove(path); err != nil {
+				return &StorageError{Path: path, Message: "unable to remove log file", Err: err}
+			}
+		}
+
+		return nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	var builder strings.Builder
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		builder.Write(line)
+		builder.WriteString("\n")
+	}
+
+	// write to a temporary file first so an interrupted rewrite can't truncate the log
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(builder.String()), 0644); err != nil {
+		return &StorageError{Path: tmpPath, Message: "unable to write log file", Err: err}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return &StorageError{Path: path, Message: "unable to write log file", Err: err}
+	}
+
+	return nil
+}
+
+func _appendLogEntry(entry LogEntry) error {
+	day := _entryDay(entry)
+	path := _logFilePath(day)
+
+	if _logFileExists(day) == false {
+		if err := _createLogFile(day); err != nil {
+			return err
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return &StorageError{Path: path, Message: "unable to open log file", Err: err}
+	}
+	defer logFile.Close()
+
+	if _, err = logFile.Write(append(line, '\n')); err != nil {
+		return &StorageError{Path: path, Message: "unable to write log file", Err: err}
+	}
+
+	return nil
+}
+
+// replace the stored entry with the same id in its day file.
+func _updateLogEntry(entry LogEntry) error {
+	day := _entryDay(entry)
+	entries, err := _readLogFile(day)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if entries[i].Id == entry.Id {
+			entries[i] = entry
+		}
+	}
+
+	return _writeLogFile(day, entries)
+}
+
+// day files in the log directory, oldest first.
+func _listLogDays() ([]string, error) {
+	path := _getHomeDir() + "/.timer/logs"
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, &StorageError{Path: path, Message: "unable to list log files", Err: err}
+	}
+
+	var days []string
+
+	for _, file := range files {
+		if _, err := time.Parse("2006-01-02", file.Name()); err == nil && !file.IsDir() {
+			days = append(days, file.Name())
+		}
+	}
+
+	return days, nil
+}
+
+// first logged entry overlapping the start to end interval, checks the day before for entries spanning midnight.
+// the entry with the excluded id, such as the one being edited, is skipped.
+func _findOverlappingEntry(start, end time.Time, excludeId string) (LogEntry, bool) {
+	for day := start.AddDate(0, 0, -1); !day.After(end); day = day.AddDate(0, 0, 1) {
+		entries, err := _readLogFile(day.Format("2006-01-02"))
+		check(err)
+
+		for _, entry := range entries {
+			if entry.Id != excludeId && entry.Start.Before(end) && start.Before(entry.End) {
+				return entry, true
+			}
+		}
+	}
+
+	return LogEntry{}, false
+}
+
+// yyyy-mm-dd days from one date to another inclusive.
+func _daysInRange(from, to string) ([]string, error) {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, &UsageError{Message: fmt.Sprintf("invalid date %q, expected yyyy-mm-dd", from)}
+	}
+
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, &UsageError{Message: fmt.Sprintf("invalid date %q, expected yyyy-mm-dd", to)}
+	}
+
+	if toDate.Before(fromDate) {
+		return nil, &UsageError{Message: fmt.Sprintf("cannot read log from %s to %s", from, to)}
+	}
+
+	var days []string
+
+	for day := fromDate; !day.After(toDate); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+
+	return days, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestParseLegacyLogLine(t *testing.T) {
+	description := base64.StdEncoding.EncodeToString([]byte("fixed, then tested"))
+	line := "ABC-1,1h 30m 0s,2026-10-16T09:00:00+10:00,2026-10-16T11:00:00+10:00," + description
+
+	entry, err := _parseLogLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Version != logFormatVersion || entry.Task != "ABC-1" || entry.Seconds != 5400 || entry.Description != "fixed, then tested" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if !entry.Start.Equal(time.Date(2026, 10, 15, 23, 0, 0, 0, time.UTC)) || !entry.End.Equal(time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start %s or end %s", entry.Start, entry.End)
+	}
+
+	if entry.Upstream.State != syncStateUnknown {
+		t.Errorf("expected sync state %s, got %s", syncStateUnknown, entry.Upstream.State)
+	}
+
+	// ids of legacy rows are stable so an entry can be updated before the file is migrated
+	again, _ := _parseLogLine(line)
+	if entry.Id == "" || again.Id != entry.Id {
+		t.Errorf("expected a stable id, got %q and %q", entry.Id, again.Id)
+	}
+}
+
+func TestParseLegacyLogLineTaskWithCommas(t *testing.T) {
+	entry, err := _parseLogLine("fix login, signup,1h 0m 0s,2026-10-16T09:00:00Z,2026-10-16T10:00:00Z,")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Task != "fix login, signup" || entry.Seconds != 3600 || entry.Description != "" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestParseLegacyLogLineMalformed(t *testing.T) {
+	for _, line := range []string{
+		"ABC-1,1h 0m 0s,2026-10-16T09:00:00Z",
+		"ABC-1,1h 0m 0s,yesterday,2026-10-16T10:00:00Z,",
+		"ABC-1,an hour,2026-10-16T09:00:00Z,2026-10-16T10:00:00Z,",
+		"ABC-1,1h 0m 0s,2026-10-16T09:00:00Z,2026-10-16T10:00:00Z,not base64!",
+	} {
+		if _, err := _parseLogLine(line); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	entry, err := _parseLogLine(`{"version":1,"id":"373b2027e19401c4","task":"ABC-1","seconds":3600,"start":"2026-10-16T09:00:00Z","end":"2026-10-16T10:00:00Z","job_type":"Code Review","status":"Billable","description":"","upstream":{"service":"jira","state":"synced"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Id != "373b2027e19401c4" || entry.JobType != "Code Review" || entry.Upstream.Service != "jira" || entry.Upstream.State != syncStateSynced {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
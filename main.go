@@ -4,16 +4,33 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 )
 
-// @TODO: Update for multi integration
 type TimerConfig struct {
-	billable_enable     bool
-	upstream_service    string
-	JiraServiceConfig   JiraConfig
-	GitlabServiceConfig GitlabConfig
+	billable_enable  bool
+	import_csv_map   string
+	job_types        map[string][]string
+	job_types_other  bool
+	default_job_type string
+	default_status   string
+	webhook_url      string
+	webhook_secret   string
+	// upstream services in config order, settings outside a [profile] section belong to the "default" profile
+	Profiles []UpstreamProfile
+}
+
+type UpstreamProfile struct {
+	Name    string
+	Service string
+	// tasks matching this regular expression are routed to the profile
+	Match string
+	// tasks started within this directory are routed to the profile
+	Dir string
+	// url, token and other settings passed to the provider
+	Settings map[string]string
 }
 
 type TaskDescription struct {
@@ -22,8 +39,21 @@ type TaskDescription struct {
 	Description string
 }
 
+type PauseInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+type TimerStatus struct {
+	Task    string
+	Start   time.Time
+	Pauses  []PauseInterval
+	Profile string
+}
+
 var config = TimerConfig{
 	billable_enable: false,
+	job_types:       map[string][]string{},
 }
 
 /**
@@ -31,22 +61,81 @@ var config = TimerConfig{
  * Primary commands and arguments defined here.
  */
 func main() {
+	defer _recoverPanic()
+
+	// --debug may be given anywhere and shows the full trace of errors instead of a message
+	var args []string
+	for _, arg := range os.Args {
+		if arg == "--debug" || arg == "-debug" {
+			debugMode = true
+		} else {
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+
 	if _baseDirExists() != true {
-		_createBaseDir()
+		check(_createBaseDir())
 	}
 
-	_readConfig()
+	check(_readConfig())
 
 	startCmd := flag.NewFlagSet("start", flag.ExitOnError)
 	startAtTime := startCmd.String("at", "", "at")
 
 	stopCmd := flag.NewFlagSet("stop", flag.ExitOnError)
 	stopAtTime := stopCmd.String("at", "", "at")
+	stopInfo, stopNoPrompt := _taskDescriptionFlags(stopCmd)
+
+	switchCmd := flag.NewFlagSet("switch", flag.ExitOnError)
+	switchAtTime := switchCmd.String("at", "", "at")
+	switchInfo, switchNoPrompt := _taskDescriptionFlags(switchCmd)
+
+	pauseCmd := flag.NewFlagSet("pause", flag.ExitOnError)
+	pauseAtTime := pauseCmd.String("at", "", "at")
+
+	resumeCmd := flag.NewFlagSet("resume", flag.ExitOnError)
+	resumeAtTime := resumeCmd.String("at", "", "at")
 
 	logCmd := flag.NewFlagSet("log", flag.ExitOnError)
 	fromDate := logCmd.String("f", "", "f")
 	toDate := logCmd.String("t", time.Now().Format("2006-01-02"), "t")
 
+	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	addFrom := addCmd.String("from", "", "from")
+	addTo := addCmd.String("to", "", "to")
+	addDate := addCmd.String("d", "", "d")
+	addPush := addCmd.Bool("push", false, "push")
+	addInfo, addNoPrompt := _taskDescriptionFlags(addCmd)
+
+	pushCmd := flag.NewFlagSet("push", flag.ExitOnError)
+	pushFromDate := pushCmd.String("f", time.Now().Format("2006-01-02"), "f")
+	pushToDate := pushCmd.String("t", time.Now().Format("2006-01-02"), "t")
+	pushDryRun := pushCmd.Bool("dry-run", false, "dry-run")
+	pushIncludeUnknown := pushCmd.Bool("include-unknown", false, "include-unknown")
+
+	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+	reportFromDate := reportCmd.String("f", time.Now().Format("2006-01-02"), "f")
+	reportToDate := reportCmd.String("t", time.Now().Format("2006-01-02"), "t")
+	reportGroupBy := reportCmd.String("group-by", "task", "group-by")
+
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	exportFormat := exportCmd.String("format", "csv", "format")
+	exportFromDate := exportCmd.String("f", time.Now().Format("2006-01-02"), "f")
+	exportToDate := exportCmd.String("t", time.Now().Format("2006-01-02"), "t")
+	exportOutput := exportCmd.String("o", "", "o")
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	importFormat := importCmd.String("format", "", "format")
+	importMap := importCmd.String("map", "", "map")
+	importDryRun := importCmd.Bool("dry-run", false, "dry-run")
+
+	editCmd := flag.NewFlagSet("edit", flag.ExitOnError)
+	editDate := editCmd.String("d", "", "d")
+
+	deleteCmd := flag.NewFlagSet("delete", flag.ExitOnError)
+	deleteDate := deleteCmd.String("d", "", "d")
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(0)
@@ -70,7 +159,46 @@ func main() {
 	case "stop":
 		stopCmd.Parse(os.Args[2:])
 
-		stop(*stopAtTime)
+		stop(*stopAtTime, *stopInfo, *stopNoPrompt)
+	case "switch":
+		switchCmd.Parse(os.Args[2:])
+
+		if len(switchCmd.Args()) > 0 {
+			switchTask(switchCmd.Args()[0], *switchAtTime, *switchInfo, *switchNoPrompt)
+		} else {
+			fmt.Println("No task name provided.")
+			os.Exit(1)
+		}
+	case "add":
+		// the task may be given before or after the flags
+		args := os.Args[2:]
+		var task string
+
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			task = args[0]
+			args = args[1:]
+		}
+
+		addCmd.Parse(args)
+
+		if task == "" && len(addCmd.Args()) > 0 {
+			task = addCmd.Args()[0]
+		}
+
+		if task == "" {
+			fmt.Println("No task name provided.")
+			os.Exit(1)
+		}
+
+		add(task, *addFrom, *addTo, *addDate, *addPush, *addInfo, *addNoPrompt)
+	case "pause":
+		pauseCmd.Parse(os.Args[2:])
+
+		pause(*pauseAtTime)
+	case "resume":
+		resumeCmd.Parse(os.Args[2:])
+
+		resume(*resumeAtTime)
 	case "cancel":
 		cancel()
 	case "log":
@@ -80,6 +208,39 @@ func main() {
 		} else {
 			logDay(time.Now())
 		}
+	case "edit":
+		editCmd.Parse(os.Args[2:])
+
+		editEntry(*editDate)
+	case "delete":
+		deleteCmd.Parse(os.Args[2:])
+
+		deleteEntry(*deleteDate)
+	case "sync":
+		sync()
+	case "report":
+		reportCmd.Parse(os.Args[2:])
+
+		report(*reportFromDate, *reportToDate, *reportGroupBy)
+	case "export":
+		exportCmd.Parse(os.Args[2:])
+
+		export(*exportFormat, *exportFromDate, *exportToDate, *exportOutput)
+	case "import":
+		importCmd.Parse(os.Args[2:])
+
+		if len(importCmd.Args()) > 0 {
+			importEntries(importCmd.Args()[0], *importFormat, *importMap, *importDryRun)
+		} else {
+			fmt.Println("No file provided.")
+			os.Exit(1)
+		}
+	case "push":
+		pushCmd.Parse(os.Args[2:])
+
+		push(*pushFromDate, *pushToDate, *pushDryRun, *pushIncludeUnknown)
+	case "migrate":
+		migrate()
 	case "ps1":
 		ps1Complication()
 	case "precmd":
@@ -94,18 +255,43 @@ func main() {
 	}
 }
 
+// -type, -status and -m fill the task description instead of prompting, --no-prompt never prompts.
+func _taskDescriptionFlags(cmd *flag.FlagSet) (*TaskDescription, *bool) {
+	var info TaskDescription
+
+	cmd.StringVar(&info.JobType, "type", "", "type")
+	cmd.StringVar(&info.Status, "status", "", "status")
+	cmd.StringVar(&info.Description, "m", "", "m")
+	noPrompt := cmd.Bool("no-prompt", false, "no-prompt")
+
+	return &info, noPrompt
+}
+
 func printUsage() {
 	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
 
 	fmt.Fprintln(writer, "usage: timer [command args]\n"+
 		"\tstart\t [-at 00:00] [task]\t Start tracking time for a task identifier, may be of an upstream task format or unformatted.\n"+
-		"\tstop\t [-at 00:00] \t Stop tracking time.\n"+
+		"\tstop\t [-at 00:00] \t Stop tracking time, [-type job] [-status Billable] [-m description] [--no-prompt] skip the prompts.\n"+
+		"\tswitch\t [-at 00:00] [task]\t Stop the current task and start tracking another at the same time.\n"+
+		"\tadd\t -from 00:00 -to 00:00 [task]\t Log time for a task after the fact, [-d yyyy-mm-dd] for another day, [-push] submits the worklog upstream.\n"+
+		"\tpause\t [-at 00:00] \t Pause tracking time, paused time is not logged.\n"+
+		"\tresume\t [-at 00:00] \t Resume tracking time for a paused task.\n"+
 		"\tcancel\t\t Cancel tracking time.\n"+
 		"\tstatus\t\t Prints time tracking status.\n"+
 		"\tlog\t [-f yyyy-mm-dd]\t Print log of the current day or from a specified date.\n"+
+		"\treport\t [-f yyyy-mm-dd] [-t yyyy-mm-dd]\t Print totals, [--group-by task|jobtype|day|week|billable] defaults to task.\n"+
+		"\texport\t [-f yyyy-mm-dd] [-t yyyy-mm-dd]\t Export logged entries, [--format csv|json|ics] defaults to csv, [-o file] defaults to stdout.\n"+
+		"\timport\t [--format csv|json] [-map field:column,...] [file]\t Import entries skipping duplicates, fails on overlaps, [--dry-run] prints what would be imported.\n"+
+		"\tsync\t\t Retry worklogs and webhook events that failed to submit.\n"+
+		"\tpush\t [-f yyyy-mm-dd] [-t yyyy-mm-dd]\t Submit logged entries not yet synced upstream, [--dry-run] prints what would be sent.\n"+
+		"\tedit\t [-d yyyy-mm-dd]\t Pick a logged entry of the current or a specified day and edit it.\n"+
+		"\tdelete\t [-d yyyy-mm-dd]\t Pick a logged entry of the current or a specified day and delete it.\n"+
+		"\tmigrate\t\t Rewrite log files from the legacy format to the current log format.\n"+
 		"\tconfig\t\t Print current loaded config.")
 
 	fmt.Fprintln(writer, "Advanced usage:\n"+
+		"\t--debug\t Show the full trace of errors, may be combined with any command.\n"+
 		"\tps1\t Output prompt complication.\n"+
 		"\tprecmd\t Check current directory and prompt to start time tracking, for use as zsh precommmand function.")
 
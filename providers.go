@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// an upstream issue resolved from a task identifier, metadata fields are zero when the tracker doesn't provide them.
+type TrackerIssue struct {
+	// the tracker's own id when it differs from the key
+	Id              string `json:"id,omitempty"`
+	Key             string `json:"key"`
+	Project         string `json:"project"`
+	Title           string `json:"title"`
+	State           string `json:"state"`
+	Open            bool   `json:"open"`
+	EstimateSeconds int64  `json:"estimate_seconds"`
+	SpentSeconds    int64  `json:"spent_seconds"`
+}
+
+// a span of logged time to record against an upstream issue.
+type WorkLog struct {
+	Info    TaskDescription
+	Start   time.Time
+	Seconds int64
+}
+
+// an upstream issue tracker time can be logged to.
+type TrackerProvider interface {
+	Name() string
+	// whether the task identifier looks like an issue of this tracker
+	MatchesTask(task string) bool
+	// find the issue for a task identifier
+	ResolveIssue(task string) (TrackerIssue, error)
+	SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error
+}
+
+// builds a provider from the upstream settings of the config.
+type ProviderFactory func(settings map[string]string) TrackerProvider
+
+var trackerProviders = map[string]ProviderFactory{}
+
+// called from the init of each integration.
+func _registerProvider(service string, factory ProviderFactory) {
+	trackerProviders[service] = factory
+}
+
+func _providerNames() []string {
+	var names []string
+
+	for name := range trackerProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+const defaultProfile = "default"
+
+func _profileIndex(name string) int {
+	for i, profile := range config.Profiles {
+		if profile.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func _newProfileProvider(profile UpstreamProfile) (TrackerProvider, error) {
+	factory, exists := trackerProviders[profile.Service]
+	if !exists {
+		return nil, &ConfigError{Message: fmt.Sprintf("unknown upstream_service %q of profile %s, expected one of %v", profile.Service, profile.Name, _providerNames())}
+	}
+
+	return factory(profile.Settings), nil
+}
+
+// pick the profile for a task: an explicit profile:task prefix, then the profile whose dir contains the
+// working directory, then a profile whose match expression matches, then the first whose provider recognises the task.
+// returns the task without its prefix and an empty profile when nothing applies.
+func _routeTask(task string, workingDir string) (string, string) {
+	if parts := strings.SplitN(task, ":", 2); len(parts) == 2 && _profileIndex(parts[0]) != -1 {
+		return parts[1], parts[0]
+	}
+
+	if workingDir != "" {
+		var longest string
+		var dirProfile string
+
+		for _, profile := range config.Profiles {
+			dir := filepath.Clean(_expandHome(profile.Dir))
+
+			if profile.Service == "" || profile.Dir == "" || len(dir) <= len(longest) {
+				continue
+			}
+
+			if workingDir == dir || strings.HasPrefix(workingDir, dir+string(filepath.Separator)) {
+				longest = dir
+				dirProfile = profile.Name
+			}
+		}
+
+		if dirProfile != "" {
+			return task, dirProfile
+		}
+	}
+
+	for _, profile := range config.Profiles {
+		if profile.Service != "" && profile.Match != "" && regexp.MustCompile(profile.Match).MatchString(task) {
+			return task, profile.Name
+		}
+	}
+
+	for _, profile := range config.Profiles {
+		if profile.Service == "" {
+			continue
+		}
+
+		provider, err := _newProfileProvider(profile)
+		check(err)
+
+		if provider.MatchesTask(task) {
+			return task, profile.Name
+		}
+	}
+
+	return task, ""
+}
+
+// route a task given on the command line using the current working directory.
+func _routeTaskHere(task string) (string, string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+
+	return _routeTask(task, cwd)
+}
+
+// the provider a task should be logged to, the profile is routed by task when not already known.
+// false when the task isn't an issue of the profile's upstream.
+func _providerForTask(task, profileName string) (TrackerProvider, bool) {
+	if profileName == "" {
+		_, profileName = _routeTask(task, "")
+	}
+
+	index := _profileIndex(profileName)
+	if index == -1 || config.Profiles[index].Service == "" {
+		return nil, false
+	}
+
+	provider, err := _newProfileProvider(config.Profiles[index])
+	check(err)
+
+	if !provider.MatchesTask(task) {
+		return nil, false
+	}
+
+	return provider, true
+}
+
+func _summaryComment(info TaskDescription) string {
+	if config.billable_enable {
+		return fmt.Sprintf("Job Type: %s\nStatus: %s\nDescription: %s", info.JobType, info.Status, info.Description)
+	}
+
+	return fmt.Sprintf("Job Type: %s\nDescription: %s", info.JobType, info.Description)
+}
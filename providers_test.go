@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestRouteTask(t *testing.T) {
+	profiles := config.Profiles
+	t.Cleanup(func() { config.Profiles = profiles })
+
+	config.Profiles = []UpstreamProfile{
+		{Name: "client", Service: "jira", Match: "^CL-[0-9]+$", Dir: "/work/client", Settings: map[string]string{}},
+		{Name: "internal", Service: "gitlab", Dir: "/work", Settings: map[string]string{}},
+		{Name: "oss", Service: "github", Settings: map[string]string{}},
+	}
+
+	for _, test := range []struct {
+		task, workingDir      string
+		expectedTask, profile string
+	}{
+		// an explicit profile prefix wins over everything else
+		{"internal:CL-5", "/work/client", "CL-5", "internal"},
+		// the longest dir containing the working directory
+		{"ABC-1", "/work/client/app", "ABC-1", "client"},
+		{"ABC-1", "/work/tools", "ABC-1", "internal"},
+		{"ABC-1", "/work", "ABC-1", "internal"},
+		// then match expressions
+		{"CL-9", "/home", "CL-9", "client"},
+		// then the first profile whose service recognises the task
+		{"ABC-1", "/workshop", "ABC-1", "client"},
+		{"#12", "", "#12", "oss"},
+		{"fix login", "", "fix login", ""},
+		// a prefix that isn't a profile is part of the task
+		{"lunch:break", "", "lunch:break", ""},
+	} {
+		task, profile := _routeTask(test.task, test.workingDir)
+
+		if task != test.expectedTask || profile != test.profile {
+			t.Errorf("_routeTask(%q, %q) = %q, %q, expected %q, %q", test.task, test.workingDir, task, profile, test.expectedTask, test.profile)
+		}
+	}
+}
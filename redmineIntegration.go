@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RedmineConfig struct {
+	Url   string
+	Token string
+	// job type to time entry activity id
+	Activities        map[string]int
+	DefaultActivityId int
+}
+
+type RedmineIssue struct {
+	Id             int32              `json:"id"`
+	Subject        string             `json:"subject"`
+	Project        RedmineNamedObject `json:"project"`
+	Status         RedmineIssueStatus `json:"status"`
+	EstimatedHours float64            `json:"estimated_hours"`
+	SpentHours     float64            `json:"spent_hours"`
+}
+
+type RedmineNamedObject struct {
+	Id   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type RedmineIssueStatus struct {
+	Name     string `json:"name"`
+	IsClosed bool   `json:"is_closed"`
+}
+
+type RedmineProvider struct {
+	config RedmineConfig
+	client *http.Client
+}
+
+// #1234 or a 1234-branch-name
+var redmineTaskFormat = regexp.MustCompile(`^#([0-9]+)$|^([0-9]+)-[\w.-]+$`)
+
+func init() {
+	_registerProvider("redmine", func(settings map[string]string) TrackerProvider {
+		activities, defaultActivityId := _parseActivityMap(settings["activity_map"], settings["default_activity_id"])
+
+		return &RedmineProvider{
+			config: RedmineConfig{
+				Url:               strings.TrimSuffix(settings["url"], "/"),
+				Token:             settings["token"],
+				Activities:        activities,
+				DefaultActivityId: defaultActivityId,
+			},
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	})
+}
+
+// parse a job type:activity id list such as Development:9,Code Review:10, entries that aren't numeric are ignored.
+func _parseActivityMap(activityMap string, defaultActivity string) (map[string]int, int) {
+	activities := map[string]int{}
+
+	for _, pair := range strings.Split(activityMap, ",") {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if id, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+			activities[strings.TrimSpace(parts[0])] = id
+		}
+	}
+
+	defaultActivityId, _ := strconv.Atoi(strings.TrimSpace(defaultActivity))
+
+	return activities, defaultActivityId
+}
+
+func isRedmineTaskFormat(identifier string) bool {
+	return redmineTaskFormat.MatchString(identifier)
+}
+
+func (p *RedmineProvider) Name() string {
+	return "redmine"
+}
+
+func (p *RedmineProvider) MatchesTask(task string) bool {
+	return isRedmineTaskFormat(task)
+}
+
+func (p *RedmineProvider) loadIssue(id string) (TrackerIssue, error) {
+	response, err := p.apiRequest("GET", fmt.Sprintf("/issues/%s.json", id), nil)
+	if err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: "redmine", Message: "unable to reach redmine", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return TrackerIssue{}, &UpstreamError{Service: "redmine", Message: fmt.Sprintf("issue #%s not found", id), StatusCode: response.StatusCode}
+	}
+
+	var body struct {
+		Issue RedmineIssue `json:"issue"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return TrackerIssue{}, &UpstreamError{Service: "redmine", Message: "unexpected issue response", Err: err}
+	}
+
+	return TrackerIssue{
+		Key:             fmt.Sprint(body.Issue.Id),
+		Project:         body.Issue.Project.Name,
+		Title:           body.Issue.Subject,
+		State:           body.Issue.Status.Name,
+		Open:            !body.Issue.Status.IsClosed,
+		EstimateSeconds: int64(body.Issue.EstimatedHours * 3600),
+		SpentSeconds:    int64(body.Issue.SpentHours * 3600),
+	}, nil
+}
+
+func (p *RedmineProvider) ResolveIssue(task string) (TrackerIssue, error) {
+	if p.config.Url == "" || p.config.Token == "" {
+		return TrackerIssue{}, &ConfigError{Message: "redmine requires url and token in the config"}
+	}
+
+	match := redmineTaskFormat.FindStringSubmatch(task)
+	if match == nil {
+		return TrackerIssue{}, &UsageError{Message: fmt.Sprintf("%s is not a redmine issue identifier", task)}
+	}
+
+	id := match[1]
+	if id == "" {
+		id = match[2]
+	}
+
+	return p.loadIssue(id)
+}
+
+func (p *RedmineProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	issueId, err := strconv.Atoi(issue.Key)
+	if err != nil {
+		return err
+	}
+
+	timeEntry := map[string]interface{}{
+		"issue_id": issueId,
+		"hours":    float64(workLog.Seconds) / 3600,
+		"spent_on": workLog.Start.Local().Format("2006-01-02"),
+		"comments": workLog.Info.Description,
+	}
+
+	// without an activity redmine uses its default time entry activity
+	if activityId, exists := p.config.Activities[workLog.Info.JobType]; exists {
+		timeEntry["activity_id"] = activityId
+	} else if p.config.DefaultActivityId != 0 {
+		timeEntry["activity_id"] = p.config.DefaultActivityId
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"time_entry": timeEntry})
+	if err != nil {
+		return err
+	}
+
+	response, err := p.apiRequest("POST", "/time_entries.json", body)
+	if err != nil {
+		return &UpstreamError{Service: "redmine", Message: "unable to reach redmine", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == 201 {
+		return nil
+	}
+
+	return &UpstreamError{Service: "redmine", Message: fmt.Sprintf("unable to log time to #%s", issue.Key), StatusCode: response.StatusCode}
+}
+
+func (p *RedmineProvider) apiRequest(method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.config.Url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("X-Redmine-API-Key", p.config.Token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return p.client.Do(req)
+}
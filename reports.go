@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type ReportGroup struct {
+	Key             string
+	Seconds         int64
+	BillableSeconds int64
+	Count           int
+}
+
+var reportGroupings = []string{"task", "jobtype", "day", "week", "billable"}
+
+func _reportGroupKey(entry LogEntry, groupBy string) string {
+	var key string
+
+	switch groupBy {
+	case "task":
+		key = entry.Task
+	case "jobtype":
+		key = entry.JobType
+	case "day":
+		key = _entryDay(entry)
+	case "week":
+		year, week := entry.Start.ISOWeek()
+		key = fmt.Sprintf("%d-W%02d", year, week)
+	case "billable":
+		key = entry.Status
+	}
+
+	if key == "" {
+		return "-"
+	}
+
+	return key
+}
+
+// share of the total time, 0 when only zero length entries are logged.
+func _reportPercent(group, total ReportGroup) float64 {
+	if total.Seconds == 0 {
+		return 0
+	}
+
+	return float64(group.Seconds) / float64(total.Seconds) * 100
+}
+
+/**
+ * Report
+ * Print logged time of a date range totalled by task, job type, day, week or billable status.
+ */
+func report(from, to, groupBy string) {
+	if !_contains(reportGroupings, groupBy) {
+		check(&UsageError{Message: fmt.Sprintf("unknown grouping %q, expected one of %v", groupBy, reportGroupings)})
+	}
+
+	groups := map[string]*ReportGroup{}
+	var total ReportGroup
+
+	days, err := _daysInRange(from, to)
+	check(err)
+
+	for _, day := range days {
+		entries, err := _readLogFile(day)
+		check(err)
+
+		for _, entry := range entries {
+			key := _reportGroupKey(entry, groupBy)
+
+			group, exists := groups[key]
+			if !exists {
+				group = &ReportGroup{Key: key}
+				groups[key] = group
+			}
+
+			group.Seconds += entry.Seconds
+			group.Count++
+			total.Seconds += entry.Seconds
+			total.Count++
+
+			if entry.Status == "Billable" {
+				group.BillableSeconds += entry.Seconds
+				total.BillableSeconds += entry.Seconds
+			}
+		}
+	}
+
+	var sorted []*ReportGroup
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		// dates read chronologically, everything else largest first
+		if groupBy == "day" || groupBy == "week" || sorted[i].Seconds == sorted[j].Seconds {
+			return sorted[i].Key < sorted[j].Key
+		}
+
+		return sorted[i].Seconds > sorted[j].Seconds
+	})
+
+	fmt.Println(fmt.Sprintf("Report %s to %s by %s", from, to, groupBy))
+
+	if total.Count == 0 {
+		fmt.Println("\t-")
+		os.Exit(0)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', tabwriter.AlignRight)
+
+	if config.billable_enable {
+		fmt.Fprintln(writer, "\t"+groupBy+"\tTotal\t%\tEntries\tBillable\tNot Billable")
+	} else {
+		fmt.Fprintln(writer, "\t"+groupBy+"\tTotal\t%\tEntries")
+	}
+
+	for _, group := range append(sorted, &ReportGroup{Key: "Total:", Seconds: total.Seconds, BillableSeconds: total.BillableSeconds, Count: total.Count}) {
+		row := fmt.Sprintf("\t%s\t%s\t%.1f%%\t%d", group.Key, _formatSeconds(group.Seconds), _reportPercent(*group, total), group.Count)
+
+		if config.billable_enable {
+			row += fmt.Sprintf("\t%s\t%s", _formatSeconds(group.BillableSeconds), _formatSeconds(group.Seconds-group.BillableSeconds))
+		}
+
+		fmt.Fprintln(writer, row)
+	}
+
+	writer.Flush()
+	os.Exit(0)
+}
+
+func _formatSeconds(seconds int64) string {
+	return _formatDuration(time.Duration(seconds) * time.Second)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportGroupKey(t *testing.T) {
+	entry := _newLogEntry("ABC-1", time.Date(2026, 1, 1, 9, 0, 0, 0, time.Local), time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local), 3600, TaskDescription{Status: "Billable"})
+
+	for groupBy, expected := range map[string]string{
+		"task":     "ABC-1",
+		"jobtype":  "-",
+		"day":      "2026-01-01",
+		"week":     "2026-W01",
+		"billable": "Billable",
+	} {
+		if key := _reportGroupKey(entry, groupBy); key != expected {
+			t.Errorf("%s key %q, expected %q", groupBy, key, expected)
+		}
+	}
+}
+
+func TestReportPercent(t *testing.T) {
+	if percent := _reportPercent(ReportGroup{Seconds: 900}, ReportGroup{Seconds: 3600}); percent != 25 {
+		t.Errorf("percent %v, expected 25", percent)
+	}
+
+	// only zero length entries, e.g. a task stopped straight after starting
+	if percent := _reportPercent(ReportGroup{Count: 1}, ReportGroup{Count: 1}); percent != 0 {
+		t.Errorf("percent %v, expected 0", percent)
+	}
+}
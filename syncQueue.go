@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const syncRetryAttempts = 3
+
+// a worklog that failed to submit upstream, kept until a sync succeeds.
+type QueuedWorkLog struct {
+	EntryId     string          `json:"entry_id"`
+	Day         string          `json:"day"`
+	Task        string          `json:"task"`
+	Info        TaskDescription `json:"info"`
+	Seconds     int64           `json:"seconds"`
+	Attempts    int             `json:"attempts"`
+	QueuedAt    time.Time       `json:"queued_at"`
+	LastAttempt time.Time       `json:"last_attempt"`
+}
+
+func _queueFilePath() string {
+	return _getHomeDir() + "/.timer/queue"
+}
+
+func _readQueue() ([]QueuedWorkLog, error) {
+	var queue []QueuedWorkLog
+
+	path := _queueFilePath()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return queue, nil
+	}
+	if err != nil {
+		return queue, &StorageError{Path: path, Message: "unable to read sync queue", Err: err}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		var item QueuedWorkLog
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return queue, &StorageError{Path: fmt.Sprintf("%s:%d", path, lineNumber), Message: "malformed sync queue entry", Err: err}
+		}
+
+		queue = append(queue, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return queue, &StorageError{Path: path, Message: "unable to read sync queue", Err: err}
+	}
+
+	return queue, nil
+}
+
+func _writeQueue(queue []QueuedWorkLog) error {
+	path := _queueFilePath()
+
+	if len(queue) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return &StorageError{Path: path, Message: "unable to remove sync queue", Err: err}
+		}
+
+		return nil
+	}
+
+	var builder strings.Builder
+
+	for _, item := range queue {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+
+		builder.Write(line)
+		builder.WriteString("\n")
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(builder.String()), 0644); err != nil {
+		return &StorageError{Path: tmpPath, Message: "unable to write sync queue", Err: err}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return &StorageError{Path: path, Message: "unable to write sync queue", Err: err}
+	}
+
+	return nil
+}
+
+func _enqueueWorkLog(entry LogEntry, info TaskDescription) {
+	now := time.Now()
+
+	queue, err := _readQueue()
+	check(err)
+
+	queue = append(queue, QueuedWorkLog{
+		EntryId:     entry.Id,
+		Day:         _entryDay(entry),
+		Task:        entry.Task,
+		Info:        info,
+		Seconds:     entry.Seconds,
+		Attempts:    1,
+		QueuedAt:    now,
+		LastAttempt: now,
+	})
+
+	check(_writeQueue(queue))
+
+	fmt.Println("The worklog was queued, run `timer sync` to retry.")
+}
+
+// submit the worklog for a logged entry, record the result and queue it for retry on failure.
+func _syncLogEntry(entry LogEntry, info TaskDescription) LogEntry {
+	entry.Upstream = _submitUpstreamWorkLog(entry.Task, entry.Profile, info, entry.Start, entry.Seconds)
+
+	if entry.Upstream.State != syncStateNone {
+		check(_updateLogEntry(entry))
+	}
+
+	if entry.Upstream.State == syncStateFailed {
+		_enqueueWorkLog(entry, info)
+	}
+
+	return entry
+}
+
+func _dequeueWorkLog(entryId string) {
+	var queue []QueuedWorkLog
+
+	items, err := _readQueue()
+	check(err)
+
+	for _, item := range items {
+		if item.EntryId != entryId {
+			queue = append(queue, item)
+		}
+	}
+
+	check(_writeQueue(queue))
+}
+
+func _isQueued(entryId string) bool {
+	queue, err := _readQueue()
+	check(err)
+
+	for _, item := range queue {
+		if item.EntryId == entryId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func _pendingSyncCount() int {
+	queue, err := _readQueue()
+	check(err)
+
+	return len(queue)
+}
+
+// find the logged entry a queued worklog was created for, it may have moved day since.
+func _findQueuedEntry(item QueuedWorkLog) (LogEntry, bool) {
+	entries, err := _readLogFile(item.Day)
+	check(err)
+
+	for _, entry := range entries {
+		if entry.Id == item.EntryId {
+			return entry, true
+		}
+	}
+
+	days, err := _listLogDays()
+	check(err)
+
+	for _, day := range days {
+		entries, err := _readLogFile(day)
+		check(err)
+
+		for _, entry := range entries {
+			if entry.Id == item.EntryId {
+				return entry, true
+			}
+		}
+	}
+
+	return LogEntry{}, false
+}
+
+/**
+ * Sync
+ * Retry queued worklog submissions, each is attempted a few times with an increasing delay.
+ */
+func sync() {
+	queue, err := _readQueue()
+	check(err)
+
+	webhooks, err := _readWebhookQueue()
+	check(err)
+
+	if len(queue) == 0 && len(webhooks) == 0 {
+		fmt.Println("No worklogs pending.")
+		os.Exit(0)
+	}
+
+	var remaining []QueuedWorkLog
+
+	for _, item := range queue {
+		entry, found := _findQueuedEntry(item)
+
+		if !found {
+			fmt.Println(fmt.Sprintf("Dropping queued worklog for %s, the log entry no longer exists.", item.Task))
+			continue
+		}
+
+		var result UpstreamSync
+		delay := time.Second
+
+		for attempt := 0; attempt < syncRetryAttempts; attempt++ {
+			if attempt > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+
+			item.Attempts++
+			item.LastAttempt = time.Now()
+
+			// use the stored entry so edits made since the failure are submitted
+			result = _submitUpstreamWorkLog(entry.Task, entry.Profile, _entryTaskDescription(entry), entry.Start, entry.Seconds)
+
+			if result.State != syncStateFailed {
+				break
+			}
+		}
+
+		entry.Upstream = result
+		check(_updateLogEntry(entry))
+
+		switch result.State {
+		case syncStateFailed:
+			remaining = append(remaining, item)
+		case syncStateSynced:
+			fmt.Println(fmt.Sprintf("Synced %s %s.", entry.Task, _formatDuration(_entryDuration(entry))))
+		default:
+			// rejected, or edited since so there is nothing to log upstream
+			fmt.Println(fmt.Sprintf("Dropping queued worklog for %s, it can't be logged upstream.", entry.Task))
+		}
+	}
+
+	check(_writeQueue(remaining))
+
+	remainingWebhooks := _syncWebhooks(webhooks)
+	check(_writeWebhookQueue(remainingWebhooks))
+
+	if len(remaining) > 0 {
+		fmt.Println(fmt.Sprintf("%d worklogs still pending.", len(remaining)))
+	}
+
+	if len(remainingWebhooks) > 0 {
+		fmt.Println(fmt.Sprintf("%d webhook events still pending.", len(remainingWebhooks)))
+	}
+
+	if len(remaining) > 0 || len(remainingWebhooks) > 0 {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+/**
+ * Push
+ * Submit logged entries of a date range that have not been synced upstream yet.
+ */
+func push(from, to string, dryRun, includeUnknown bool) {
+	if len(config.Profiles) == 0 {
+		check(&ConfigError{Message: "no upstream_service configured"})
+	}
+
+	var pushed, failed, skippedUnknown int
+
+	days, err := _daysInRange(from, to)
+	check(err)
+
+	for _, day := range days {
+		entries, err := _readLogFile(day)
+		check(err)
+
+		for _, entry := range entries {
+			if entry.Upstream.State == syncStateSynced || entry.Seconds == 0 || !_isUpstreamTask(entry.Task, entry.Profile) {
+				continue
+			}
+
+			// migrated entries may already have been submitted when they were stopped
+			if entry.Upstream.State == syncStateUnknown && !includeUnknown {
+				skippedUnknown++
+				continue
+			}
+
+			if dryRun {
+				fmt.Println(fmt.Sprintf("Would push %s %s %s %s %s", day, entry.Task, _formatDuration(_entryDuration(entry)), entry.JobType, entry.Description))
+				pushed++
+				continue
+			}
+
+			info := _entryTaskDescription(entry)
+			entry.Upstream = _submitUpstreamWorkLog(entry.Task, entry.Profile, info, entry.Start, entry.Seconds)
+			check(_updateLogEntry(entry))
+
+			switch entry.Upstream.State {
+			case syncStateFailed:
+				if !_isQueued(entry.Id) {
+					_enqueueWorkLog(entry, info)
+				}
+				failed++
+			case syncStateRejected:
+				_dequeueWorkLog(entry.Id)
+				failed++
+			default:
+				_dequeueWorkLog(entry.Id)
+				fmt.Println(fmt.Sprintf("Pushed %s %s %s", day, entry.Task, _formatDuration(_entryDuration(entry))))
+				pushed++
+			}
+		}
+	}
+
+	if dryRun {
+		fmt.Println(fmt.Sprintf("%d entries would be pushed upstream.", pushed))
+	} else {
+		fmt.Println(fmt.Sprintf("%d entries pushed upstream, %d failed.", pushed, failed))
+	}
+
+	if skippedUnknown > 0 {
+		fmt.Println(fmt.Sprintf("%d migrated entries with an unknown sync state were skipped, use -include-unknown to push them.", skippedUnknown))
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubmitUpstreamWorkLogResult(t *testing.T) {
+	var requests int
+	status := 201
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Method == "GET" {
+			w.Write([]byte(`{"number":12,"state":"open","title":"Fix login"}`))
+			return
+		}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	profiles := config.Profiles
+	t.Cleanup(func() { config.Profiles = profiles })
+
+	config.Profiles = []UpstreamProfile{
+		{Name: "oss", Service: "github", Settings: map[string]string{"url": server.URL, "token": "secret", "default_repository": "acme/web"}},
+	}
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)
+
+	for _, test := range []struct {
+		status   int
+		seconds  int64
+		expected string
+	}{
+		{201, 3600, syncStateSynced},
+		// temporary failures are queued for sync
+		{500, 3600, syncStateFailed},
+		{429, 3600, syncStateFailed},
+		{401, 3600, syncStateFailed},
+		// retrying won't change the answer
+		{404, 3600, syncStateRejected},
+		{422, 3600, syncStateRejected},
+	} {
+		status = test.status
+
+		if result := _submitUpstreamWorkLog("#12", "oss", TaskDescription{}, start, test.seconds); result.State != test.expected {
+			t.Errorf("status %d gave sync state %s, expected %s", test.status, result.State, test.expected)
+		}
+	}
+
+	requests = 0
+
+	if result := _submitUpstreamWorkLog("#12", "oss", TaskDescription{}, start, 0); result.State != syncStateNone || requests != 0 {
+		t.Errorf("expected an empty worklog not to be submitted, got %s after %d requests", result.State, requests)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TimesheetConfig struct {
+	Template    string
+	Url         string
+	Token       string
+	WorkspaceId string
+	AccountId   string
+	// harvest tasks of billable and not billable time
+	TaskId            string
+	NonBillableTaskId string
+	// task prefix to timesheet project id
+	Projects         map[string]string
+	DefaultProjectId string
+}
+
+type TimesheetProvider struct {
+	config TimesheetConfig
+	client *http.Client
+}
+
+// request shapes of the supported timesheet products, generic posts a flat json entry to url.
+var timesheetTemplates = []string{"generic", "clockify", "toggl", "harvest"}
+
+var timesheetDefaultUrls = map[string]string{
+	"clockify": "https://api.clockify.me/api/v1",
+	"toggl":    "https://api.track.toggl.com/api/v9",
+	"harvest":  "https://api.harvestapp.com/v2",
+}
+
+func init() {
+	_registerProvider("timesheet", func(settings map[string]string) TrackerProvider {
+		template := settings["template"]
+		if template == "" {
+			template = "generic"
+		}
+
+		apiUrl := settings["url"]
+		if apiUrl == "" {
+			apiUrl = timesheetDefaultUrls[template]
+		}
+
+		return &TimesheetProvider{
+			config: TimesheetConfig{
+				Template:          template,
+				Url:               strings.TrimSuffix(apiUrl, "/"),
+				Token:             settings["token"],
+				WorkspaceId:       settings["workspace_id"],
+				AccountId:         settings["account_id"],
+				TaskId:            settings["task_id"],
+				NonBillableTaskId: settings["non_billable_task_id"],
+				Projects:          _parseSettingsMap(settings["project_map"]),
+				DefaultProjectId:  settings["default_project_id"],
+			},
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	})
+}
+
+// parse a key:value list such as PROJ:123,OPS:456, pairs without a value are ignored.
+func _parseSettingsMap(list string) map[string]string {
+	mapping := map[string]string{}
+
+	for _, pair := range strings.Split(list, ",") {
+		parts := strings.SplitN(pair, ":", 2)
+
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return mapping
+}
+
+func (p *TimesheetProvider) Name() string {
+	return p.config.Template
+}
+
+// any task with a timesheet project, timesheets have no issue identifiers
+func (p *TimesheetProvider) MatchesTask(task string) bool {
+	return p.projectId(task) != ""
+}
+
+// project of the longest project_map prefix of the task, otherwise the default project.
+func (p *TimesheetProvider) projectId(task string) string {
+	var prefixes []string
+	for prefix := range p.config.Projects {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(task, prefix) {
+			return p.config.Projects[prefix]
+		}
+	}
+
+	return p.config.DefaultProjectId
+}
+
+func (p *TimesheetProvider) ResolveIssue(task string) (TrackerIssue, error) {
+	if !_contains(timesheetTemplates, p.config.Template) {
+		return TrackerIssue{}, &ConfigError{Message: fmt.Sprintf("unknown timesheet template %q, expected one of %v", p.config.Template, timesheetTemplates)}
+	}
+
+	if p.config.Url == "" || p.config.Token == "" {
+		return TrackerIssue{}, &ConfigError{Message: "timesheet requires url and token in the config"}
+	}
+
+	projectId := p.projectId(task)
+	if projectId == "" {
+		return TrackerIssue{}, &ConfigError{Message: fmt.Sprintf("no timesheet project for %s, add it to project_map or set default_project_id", task)}
+	}
+
+	return TrackerIssue{Key: task, Project: projectId, Title: task, Open: true}, nil
+}
+
+func (p *TimesheetProvider) SubmitWorkLog(issue TrackerIssue, workLog WorkLog) error {
+	path, entry, err := p.timeEntry(issue, workLog)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	response, err := p.apiRequest("POST", path, body)
+	if err != nil {
+		return &UpstreamError{Service: p.Name(), Message: "unable to reach " + p.Name(), Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	return &UpstreamError{Service: p.Name(), Message: fmt.Sprintf("unable to create a time entry for %s", issue.Key), StatusCode: response.StatusCode}
+}
+
+// request path and body of a time entry in the shape of the configured template.
+func (p *TimesheetProvider) timeEntry(issue TrackerIssue, workLog WorkLog) (string, map[string]interface{}, error) {
+	start := workLog.Start.UTC()
+	end := start.Add(time.Duration(workLog.Seconds) * time.Second)
+	billable := workLog.Info.Status == "Billable"
+
+	description := issue.Key
+	if workLog.Info.Description != "" {
+		description = fmt.Sprintf("%s: %s", issue.Key, workLog.Info.Description)
+	}
+
+	switch p.config.Template {
+	case "clockify":
+		return fmt.Sprintf("/workspaces/%s/time-entries", p.config.WorkspaceId), map[string]interface{}{
+			"start":       start.Format(time.RFC3339),
+			"end":         end.Format(time.RFC3339),
+			"billable":    billable,
+			"description": description,
+			"projectId":   issue.Project,
+		}, nil
+	case "toggl":
+		workspaceId, err := strconv.Atoi(p.config.WorkspaceId)
+		if err != nil {
+			return "", nil, &ConfigError{Message: "toggl requires a numeric workspace_id in the config"}
+		}
+
+		projectId, err := strconv.Atoi(issue.Project)
+		if err != nil {
+			return "", nil, &ConfigError{Message: fmt.Sprintf("toggl project id %q is not numeric", issue.Project)}
+		}
+
+		return fmt.Sprintf("/workspaces/%d/time_entries", workspaceId), map[string]interface{}{
+			"created_with": "timer",
+			"workspace_id": workspaceId,
+			"project_id":   projectId,
+			"start":        start.Format(time.RFC3339),
+			"stop":         end.Format(time.RFC3339),
+			"duration":     workLog.Seconds,
+			"billable":     billable,
+			"description":  description,
+		}, nil
+	case "harvest":
+		// harvest bills by task, not per entry
+		taskId := p.config.TaskId
+		if !billable && p.config.NonBillableTaskId != "" {
+			taskId = p.config.NonBillableTaskId
+		}
+
+		if taskId == "" {
+			return "", nil, &ConfigError{Message: "harvest requires task_id in the config"}
+		}
+
+		local := workLog.Start.Local()
+
+		// accounts tracking time by duration ignore the start and end times
+		return "/time_entries", map[string]interface{}{
+			"project_id":   issue.Project,
+			"task_id":      taskId,
+			"spent_date":   local.Format("2006-01-02"),
+			"hours":        float64(workLog.Seconds) / 3600,
+			"started_time": local.Format("3:04pm"),
+			"ended_time":   local.Add(time.Duration(workLog.Seconds) * time.Second).Format("3:04pm"),
+			"notes":        description,
+		}, nil
+	}
+
+	return "", map[string]interface{}{
+		"task":        issue.Key,
+		"project_id":  issue.Project,
+		"start":       start.Format(time.RFC3339),
+		"end":         end.Format(time.RFC3339),
+		"seconds":     workLog.Seconds,
+		"billable":    billable,
+		"job_type":    workLog.Info.JobType,
+		"status":      workLog.Info.Status,
+		"description": workLog.Info.Description,
+	}, nil
+}
+
+func (p *TimesheetProvider) apiRequest(method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.config.Url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.config.Template {
+	case "clockify":
+		req.Header.Add("X-Api-Key", p.config.Token)
+	case "toggl":
+		req.SetBasicAuth(p.config.Token, "api_token")
+	case "harvest":
+		req.Header.Add("Authorization", "Bearer "+p.config.Token)
+		req.Header.Add("Harvest-Account-Id", p.config.AccountId)
+		req.Header.Add("User-Agent", "timer")
+	default:
+		req.Header.Add("Authorization", "Bearer "+p.config.Token)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return p.client.Do(req)
+}
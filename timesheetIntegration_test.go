@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHarvestTimeEntry(t *testing.T) {
+	provider := trackerProviders["timesheet"](map[string]string{"template": "harvest", "task_id": "42"}).(*TimesheetProvider)
+
+	path, entry, err := provider.timeEntry(TrackerIssue{Key: "ABC-1", Project: "7"}, WorkLog{
+		Info:    TaskDescription{Status: "Billable"},
+		Start:   time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local),
+		Seconds: 5400,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/time_entries" || entry["hours"] != 1.5 || entry["started_time"] != "9:00am" || entry["ended_time"] != "10:30am" || entry["task_id"] != "42" {
+		t.Errorf("unexpected harvest entry %s %v", path, entry)
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
+
+	"golang.org/x/term"
 )
 
 func _statusFileExists() bool {
@@ -23,32 +28,127 @@ func _logFileExists(day string) bool {
 	return err == nil
 }
 
-func _createLogFile(day string) {
+func _createLogFile(day string) error {
 	homeDir := _getHomeDir()
-	_, err := os.Create(homeDir + "/.timer/logs/" + day)
-	check(err)
+	file, err := os.Create(homeDir + "/.timer/logs/" + day)
+	if err != nil {
+		return &StorageError{Path: homeDir + "/.timer/logs/" + day, Message: "unable to create log file", Err: err}
+	}
+
+	return file.Close()
 }
 
-func _readStatusFile() (string, string) {
-	homeDir := _getHomeDir()
-	data, err := os.ReadFile(homeDir + "/.timer/status")
-	check(err)
+func _readStatusFile() (TimerStatus, error) {
+	var status TimerStatus
+
+	path := _getHomeDir() + "/.timer/status"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return status, &StorageError{Path: path, Message: "unable to read status file", Err: err}
+	}
+
+	statusInfo := strings.Split(strings.TrimSpace(string(data[:])), ",")
+
+	if len(statusInfo) < 2 {
+		return status, &StorageError{Path: path, Message: "malformed status file, expected task,start time"}
+	}
+
+	status.Task = statusInfo[0]
+	status.Start, err = time.Parse(time.RFC3339, statusInfo[1])
+	if err != nil {
+		return status, &StorageError{Path: path, Message: "malformed start time in status file", Err: err}
+	}
+
+	// remaining fields are profile=<name> and paused intervals formatted as pausedAt/resumedAt, resumedAt is empty while paused
+	for _, field := range statusInfo[2:] {
+		if strings.HasPrefix(field, "profile=") {
+			status.Profile = strings.TrimPrefix(field, "profile=")
+			continue
+		}
+
+		interval := strings.Split(field, "/")
 
-	statusInfo := strings.Split(string(data[:]), ",")
+		var pause PauseInterval
+		pause.Start, err = time.Parse(time.RFC3339, interval[0])
+		if err != nil {
+			return status, &StorageError{Path: path, Message: "malformed pause in status file", Err: err}
+		}
 
-	return statusInfo[0], strings.TrimSpace(statusInfo[1])
+		if len(interval) > 1 && interval[1] != "" {
+			pause.End, err = time.Parse(time.RFC3339, interval[1])
+			if err != nil {
+				return status, &StorageError{Path: path, Message: "malformed resume in status file", Err: err}
+			}
+		}
+
+		status.Pauses = append(status.Pauses, pause)
+	}
+
+	return status, nil
 }
 
-func _writeStatusFile(status string) {
+func _writeStatusFile(status TimerStatus) error {
+	fields := []string{status.Task, status.Start.Format(time.RFC3339)}
+
+	if status.Profile != "" {
+		fields = append(fields, "profile="+status.Profile)
+	}
+
+	for _, pause := range status.Pauses {
+		var resumedAt string
+
+		if !pause.End.IsZero() {
+			resumedAt = pause.End.Format(time.RFC3339)
+		}
+
+		fields = append(fields, pause.Start.Format(time.RFC3339)+"/"+resumedAt)
+	}
+
 	homeDir := _getHomeDir()
-	err := os.WriteFile(homeDir+"/.timer/status", []byte(status), 0644)
-	check(err)
+	err := os.WriteFile(homeDir+"/.timer/status", []byte(strings.Join(fields, ",")), 0644)
+	if err != nil {
+		return &StorageError{Path: homeDir + "/.timer/status", Message: "unable to write status file", Err: err}
+	}
+
+	return nil
+}
+
+func _isPaused(status TimerStatus) bool {
+	return len(status.Pauses) > 0 && status.Pauses[len(status.Pauses)-1].End.IsZero()
+}
+
+// total paused time up to the given time, an open pause counts until then.
+func _pausedDuration(status TimerStatus, at time.Time) time.Duration {
+	var paused time.Duration
+
+	for _, pause := range status.Pauses {
+		end := pause.End
+
+		if end.IsZero() || end.After(at) {
+			end = at
+		}
+
+		if end.After(pause.Start) {
+			paused += end.Sub(pause.Start)
+		}
+	}
+
+	return paused
+}
+
+// time tracked on the task up to the given time excluding pauses.
+func _netDuration(status TimerStatus, at time.Time) time.Duration {
+	return at.Sub(status.Start) - _pausedDuration(status, at)
 }
 
-func _removeStatusFile() {
+func _removeStatusFile() error {
 	homeDir := _getHomeDir()
 	err := os.Remove(homeDir + "/.timer/status")
-	check(err)
+	if err != nil {
+		return &StorageError{Path: homeDir + "/.timer/status", Message: "unable to remove status file", Err: err}
+	}
+
+	return _removeIssueCache()
 }
 
 func _formatDuration(duration time.Duration) string {
@@ -59,6 +159,27 @@ func _formatDuration(duration time.Duration) string {
 	return formatted
 }
 
+func _parseDuration(formatted string) (time.Duration, error) {
+	return time.ParseDuration(strings.ReplaceAll(formatted, " ", ""))
+}
+
+// parse an -at HH:MM argument as a time on the current day.
+// times are whole seconds, the precision of the status file, so a switched task starts where the last one ended.
+func _parseAtTime(atTime string) (time.Time, error) {
+	now := time.Now().Truncate(time.Second)
+
+	if atTime == "" {
+		return now, nil
+	}
+
+	at, err := time.ParseInLocation("2006-01-02T15:04:05", fmt.Sprintf("%d-%02d-%02dT%s:00", now.Year(), int(now.Month()), now.Day(), atTime), time.Local)
+	if err != nil {
+		return at, &UsageError{Message: fmt.Sprintf("invalid time %q, expected hh:mm", atTime)}
+	}
+
+	return at, nil
+}
+
 func _baseDirExists() bool {
 	homeDir := _getHomeDir()
 	_, err := os.Stat(homeDir + "/.timer")
@@ -66,83 +187,155 @@ func _baseDirExists() bool {
 	return err == nil
 }
 
-func _createBaseDir() {
+func _createBaseDir() error {
 	homeDir := _getHomeDir()
 	err := os.Mkdir(homeDir+"/.timer", 0755)
-	check(err)
+	if err != nil {
+		return &StorageError{Path: homeDir + "/.timer", Message: "unable to create directory", Err: err}
+	}
 
 	err = os.Mkdir(homeDir+"/.timer/logs", 0755)
-	check(err)
+	if err != nil {
+		return &StorageError{Path: homeDir + "/.timer/logs", Message: "unable to create directory", Err: err}
+	}
 
 	defaultConfig := "billable_enable=no"
 
 	err = os.WriteFile(homeDir+"/.timer/config", []byte(defaultConfig), 0644)
-	check(err)
+	if err != nil {
+		return &ConfigError{Message: "unable to write default config " + homeDir + "/.timer/config", Err: err}
+	}
+
+	return nil
 }
 
-func _readConfig() {
-	homeDir := _getHomeDir()
-	configFile, err := os.Open(homeDir + "/.timer/config")
-	check(err)
+func _readConfig() error {
+	path := _getHomeDir() + "/.timer/config"
+	configFile, err := os.Open(path)
+	if err != nil {
+		return &ConfigError{Message: "unable to read config " + path, Err: err}
+	}
+	defer configFile.Close()
 
 	scanner := bufio.NewScanner(configFile)
+	line := 0
+	// index in config.Profiles of the [profile] section being read, -1 before the first section
+	section := -1
 
 	for scanner.Scan() {
-		entry := strings.Split(scanner.Text(), "=")
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			name := strings.TrimSpace(text[1 : len(text)-1])
+
+			if name == "" || _profileIndex(name) != -1 {
+				return &ConfigError{Message: fmt.Sprintf("invalid or duplicate profile %q on line %d in config %s", name, line, path)}
+			}
+
+			config.Profiles = append(config.Profiles, UpstreamProfile{Name: name, Settings: map[string]string{}})
+			section = len(config.Profiles) - 1
+			continue
+		}
+
+		entry := strings.SplitN(text, "=", 2)
+
+		if len(entry) < 2 {
+			return &ConfigError{Message: fmt.Sprintf("invalid line %d in config %s, expected key=value", line, path)}
+		}
+
+		if section != -1 {
+			_setProfileValue(&config.Profiles[section], entry[0], entry[1])
+			continue
+		}
 
 		switch entry[0] {
 
 		case "billable_enable":
 			config.billable_enable = entry[1] == "yes"
 
-		case "upstream_service":
-			config.upstream_service = entry[1]
+		case "default_job_type":
+			config.default_job_type = entry[1]
 
-		case "url":
-			switch config.upstream_service {
-			case "jira":
-				config.JiraServiceConfig.Url = entry[1]
-			case "gitlab":
-				config.GitlabServiceConfig.Url = entry[1]
-			}
+		case "default_status":
+			config.default_status = entry[1]
 
-		case "token":
-			switch config.upstream_service {
-			case "jira":
-				config.JiraServiceConfig.Token = entry[1]
-			case "gitlab":
-				config.GitlabServiceConfig.Token = entry[1]
-			}
+		case "job_types_other":
+			config.job_types_other = entry[1] == "yes"
 
-		case "username":
-			switch config.upstream_service {
-			case "jira":
-				config.JiraServiceConfig.Username = entry[1]
-			}
+		case "import_csv_map":
+			config.import_csv_map = entry[1]
+
+		case "webhook_url":
+			config.webhook_url = entry[1]
 
-		case "default_gitlab_project_id":
-			config.GitlabServiceConfig.DefaultProject = entry[1]
+		case "webhook_secret":
+			config.webhook_secret = entry[1]
 
 		default:
-			continue
+			// job_types, job_types.<upstream_service> or job_types.<task prefix>
+			if entry[0] == "job_types" || strings.HasPrefix(entry[0], "job_types.") {
+				var types []string
+
+				for _, jobType := range strings.Split(entry[1], ",") {
+					if jobType = strings.TrimSpace(jobType); jobType != "" {
+						types = append(types, jobType)
+					}
+				}
+
+				config.job_types[entry[0]] = types
+				continue
+			}
+
+			// anything else (upstream_service, url, token, ...) configures the default profile
+			index := _profileIndex(defaultProfile)
+			if index == -1 {
+				config.Profiles = append([]UpstreamProfile{{Name: defaultProfile, Settings: map[string]string{}}}, config.Profiles...)
+				index = 0
+
+				if section != -1 {
+					section++
+				}
+			}
+
+			_setProfileValue(&config.Profiles[index], entry[0], entry[1])
 		}
 	}
-	check(scanner.Err())
-}
 
-func _configIsComplete() bool {
+	if err := scanner.Err(); err != nil {
+		return &ConfigError{Message: "unable to read config " + path, Err: err}
+	}
 
-	// if we have an upstream_service validate else ignore config
-	if config.upstream_service != "" {
-		switch config.upstream_service {
-		case "jira":
-			return config.JiraServiceConfig.Url != "" && config.JiraServiceConfig.Username != "" && config.JiraServiceConfig.Token != ""
-		case "gitlab":
-			return config.GitlabServiceConfig.Url != "" && config.GitlabServiceConfig.Token != ""
+	for _, profile := range config.Profiles {
+		if profile.Service == "" && profile.Name != defaultProfile {
+			return &ConfigError{Message: fmt.Sprintf("profile %s in config %s has no upstream_service", profile.Name, path)}
+		}
+
+		if profile.Match != "" {
+			if _, err := regexp.Compile(profile.Match); err != nil {
+				return &ConfigError{Message: fmt.Sprintf("invalid match of profile %s in config %s", profile.Name, path), Err: err}
+			}
 		}
 	}
 
-	return true
+	return nil
+}
+
+func _setProfileValue(profile *UpstreamProfile, key, value string) {
+	switch key {
+	case "upstream_service":
+		profile.Service = value
+	case "match":
+		profile.Match = value
+	case "dir":
+		profile.Dir = value
+	default:
+		profile.Settings[key] = value
+	}
 }
 
 func _isGitRepo() bool {
@@ -158,6 +351,87 @@ func _getHeadRef() string {
 	return string(data)
 }
 
+// url of a remote from .git/config in the current directory, empty when there is none.
+func _getGitRemoteUrl(remote string) string {
+	return _readGitRemotes()[remote]
+}
+
+// urls of all remotes in the current directory, origin first.
+func _getGitRemoteUrls() []string {
+	remotes := _readGitRemotes()
+
+	var urls []string
+	if origin, exists := remotes["origin"]; exists {
+		urls = append(urls, origin)
+	}
+
+	var names []string
+	for name := range remotes {
+		if name != "origin" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		urls = append(urls, remotes[name])
+	}
+
+	return urls
+}
+
+// remote name to url from .git/config in the current directory.
+func _readGitRemotes() map[string]string {
+	remotes := map[string]string{}
+
+	data, err := os.ReadFile(".git/config")
+	if err != nil {
+		return remotes
+	}
+
+	remote := ""
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "[") {
+			remote = ""
+			if strings.HasPrefix(line, "[remote \"") && strings.HasSuffix(line, "\"]") {
+				remote = strings.TrimSuffix(strings.TrimPrefix(line, "[remote \""), "\"]")
+			}
+			continue
+		}
+
+		if remote != "" && strings.HasPrefix(line, "url") {
+			if parts := strings.SplitN(line, "=", 2); len(parts) == 2 && strings.TrimSpace(parts[0]) == "url" {
+				remotes[remote] = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+
+	return remotes
+}
+
+// host and namespace/project path of a git remote url in the https, ssh or scp-like forms.
+func _parseGitRemote(remoteUrl string) (string, string) {
+	remoteUrl = strings.TrimSuffix(strings.TrimSuffix(remoteUrl, "/"), ".git")
+
+	if parsed, err := url.Parse(remoteUrl); err == nil && parsed.Host != "" {
+		return parsed.Hostname(), strings.Trim(parsed.Path, "/")
+	}
+
+	// scp-like git@host:namespace/project
+	if at := strings.Index(remoteUrl, "@"); at != -1 {
+		remoteUrl = remoteUrl[at+1:]
+	}
+
+	if parts := strings.SplitN(remoteUrl, ":", 2); len(parts) == 2 {
+		return parts[0], strings.Trim(parts[1], "/")
+	}
+
+	return "", ""
+}
+
 // @TODO: use $OLDPWD ?
 func _getLastWorkingDir() (string, error) {
 	homeDir := _getHomeDir()
@@ -189,14 +463,33 @@ func _getHomeDir() string {
 	}
 }
 
-func PrettyPrint(d any) {
-	jd, _ := json.MarshalIndent(d, "", "\t")
+func _expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		check(err)
 
-	fmt.Println(string(jd))
+		return homeDir + path[1:]
+	}
+
+	return path
 }
 
-func check(e error) {
-	if e != nil {
-		panic(e)
+func _isTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+func _contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+
+	return false
+}
+
+func PrettyPrint(d any) {
+	jd, _ := json.MarshalIndent(d, "", "\t")
+
+	fmt.Println(string(jd))
 }
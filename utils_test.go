@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// point the timer directory at a temporary directory, in both the go run (cwd) and installed (home) modes.
+func _useTestHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	if err := _createBaseDir(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusFileRoundTrip(t *testing.T) {
+	_useTestHome(t)
+
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	status := TimerStatus{
+		Task:    "ABC-1",
+		Start:   start,
+		Profile: "client",
+		Pauses: []PauseInterval{
+			{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)},
+			{Start: start.Add(2 * time.Hour)},
+		},
+	}
+
+	if err := _writeStatusFile(status); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := _readStatusFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Task != status.Task || read.Profile != status.Profile || !read.Start.Equal(status.Start) || len(read.Pauses) != 2 {
+		t.Fatalf("read %+v, expected %+v", read, status)
+	}
+
+	for i, pause := range read.Pauses {
+		if !pause.Start.Equal(status.Pauses[i].Start) || !pause.End.Equal(status.Pauses[i].End) {
+			t.Errorf("pause %d read %+v, expected %+v", i, pause, status.Pauses[i])
+		}
+	}
+
+	if !_isPaused(read) {
+		t.Error("expected the status to be paused")
+	}
+
+	if elapsed := _netDuration(read, start.Add(3*time.Hour)); elapsed != 90*time.Minute {
+		t.Errorf("net duration %s, expected 1h30m", elapsed)
+	}
+}
+
+func TestStatusFileWithoutProfileOrPauses(t *testing.T) {
+	_useTestHome(t)
+
+	status := TimerStatus{Task: "fix login", Start: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)}
+
+	if err := _writeStatusFile(status); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := _readStatusFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Task != status.Task || read.Profile != "" || !read.Start.Equal(status.Start) || read.Pauses != nil {
+		t.Errorf("read %+v, expected %+v", read, status)
+	}
+
+	if err := _removeStatusFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _statusFileExists() {
+		t.Error("expected the status file to be removed")
+	}
+}
+
+func TestParseGitRemote(t *testing.T) {
+	for remote, expected := range map[string][]string{
+		"https://gitlab.example.com/group/sub/project.git": {"gitlab.example.com", "group/sub/project"},
+		"git@github.com:owner/repo.git":                    {"github.com", "owner/repo"},
+		"ssh://git@gitea.example.com:2222/owner/repo":      {"gitea.example.com", "owner/repo"},
+		"": {"", ""},
+	} {
+		host, path := _parseGitRemote(remote)
+
+		if !reflect.DeepEqual([]string{host, path}, expected) {
+			t.Errorf("_parseGitRemote(%q) = %s %s, expected %v", remote, host, path, expected)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	webhookEventStart  = "start"
+	webhookEventStop   = "stop"
+	webhookEventCancel = "cancel"
+)
+
+// the json body posted to webhook_url.
+type WebhookEvent struct {
+	Id          string        `json:"id"`
+	Event       string        `json:"event"`
+	Time        time.Time     `json:"time"`
+	Task        string        `json:"task"`
+	Profile     string        `json:"profile,omitempty"`
+	Start       time.Time     `json:"start"`
+	End         *time.Time    `json:"end,omitempty"`
+	Seconds     int64         `json:"seconds"`
+	JobType     string        `json:"job_type,omitempty"`
+	Status      string        `json:"status,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Billable    bool          `json:"billable"`
+	Upstream    *UpstreamSync `json:"upstream,omitempty"`
+}
+
+// a webhook event that failed to deliver, kept until a sync succeeds.
+type QueuedWebhook struct {
+	Event       WebhookEvent `json:"event"`
+	Attempts    int          `json:"attempts"`
+	QueuedAt    time.Time    `json:"queued_at"`
+	LastAttempt time.Time    `json:"last_attempt"`
+}
+
+func _webhookQueueFilePath() string {
+	return _getHomeDir() + "/.timer/webhooks"
+}
+
+func _startWebhookEvent(status TimerStatus) WebhookEvent {
+	return WebhookEvent{
+		Id:      _newEntryId(),
+		Event:   webhookEventStart,
+		Time:    time.Now(),
+		Task:    status.Task,
+		Profile: status.Profile,
+		Start:   status.Start,
+	}
+}
+
+func _stopWebhookEvent(entry LogEntry) WebhookEvent {
+	upstream := entry.Upstream
+
+	return WebhookEvent{
+		Id:          entry.Id,
+		Event:       webhookEventStop,
+		Time:        time.Now(),
+		Task:        entry.Task,
+		Profile:     entry.Profile,
+		Start:       entry.Start,
+		End:         &entry.End,
+		Seconds:     entry.Seconds,
+		JobType:     entry.JobType,
+		Status:      entry.Status,
+		Description: entry.Description,
+		Billable:    entry.Status == "Billable",
+		Upstream:    &upstream,
+	}
+}
+
+// the seconds of a cancelled task are tracked time that was discarded.
+func _cancelWebhookEvent(status TimerStatus, cancelTime time.Time) WebhookEvent {
+	return WebhookEvent{
+		Id:      _newEntryId(),
+		Event:   webhookEventCancel,
+		Time:    time.Now(),
+		Task:    status.Task,
+		Profile: status.Profile,
+		Start:   status.Start,
+		End:     &cancelTime,
+		Seconds: int64(_netDuration(status, cancelTime).Round(time.Second) / time.Second),
+	}
+}
+
+// post an event to the configured webhook, queueing it for `timer sync` when delivery fails.
+func _sendWebhook(event WebhookEvent) {
+	if config.webhook_url == "" {
+		return
+	}
+
+	if err := _deliverWebhook(event); err != nil {
+		fmt.Println(fmt.Sprintf("Warning: the %s event for %s was not delivered to the webhook.", event.Event, event.Task))
+		_printUpstreamError(err)
+
+		now := time.Now()
+
+		queue, err := _readWebhookQueue()
+		check(err)
+
+		queue = append(queue, QueuedWebhook{Event: event, Attempts: 1, QueuedAt: now, LastAttempt: now})
+		check(_writeWebhookQueue(queue))
+
+		fmt.Println("The webhook event was queued, run `timer sync` to retry.")
+	}
+}
+
+func _deliverWebhook(event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", config.webhook_url, bytes.NewReader(body))
+	if err != nil {
+		return &ConfigError{Message: "invalid webhook_url", Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Timer-Event", event.Event)
+
+	// receivers verify the body with the shared secret
+	if config.webhook_secret != "" {
+		mac := hmac.New(sha256.New, []byte(config.webhook_secret))
+		mac.Write(body)
+		req.Header.Set("X-Timer-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return &UpstreamError{Service: "webhook", Message: "unable to reach webhook", Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &UpstreamError{Service: "webhook", Message: "webhook rejected the event", StatusCode: response.StatusCode}
+	}
+
+	return nil
+}
+
+func _readWebhookQueue() ([]QueuedWebhook, error) {
+	var queue []QueuedWebhook
+
+	path := _webhookQueueFilePath()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return queue, nil
+	}
+	if err != nil {
+		return queue, &StorageError{Path: path, Message: "unable to read webhook queue", Err: err}
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		var item QueuedWebhook
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return queue, &StorageError{Path: fmt.Sprintf("%s:%d", path, lineNumber), Message: "malformed webhook queue entry", Err: err}
+		}
+
+		queue = append(queue, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return queue, &StorageError{Path: path, Message: "unable to read webhook queue", Err: err}
+	}
+
+	return queue, nil
+}
+
+func _writeWebhookQueue(queue []QueuedWebhook) error {
+	path := _webhookQueueFilePath()
+
+	if len(queue) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return &StorageError{Path: path, Message: "unable to remove webhook queue", Err: err}
+		}
+
+		return nil
+	}
+
+	var builder strings.Builder
+
+	for _, item := range queue {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+
+		builder.Write(line)
+		builder.WriteString("\n")
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(builder.String()), 0644); err != nil {
+		return &StorageError{Path: tmpPath, Message: "unable to write webhook queue", Err: err}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return &StorageError{Path: path, Message: "unable to write webhook queue", Err: err}
+	}
+
+	return nil
+}
+
+// retry queued webhook events in order, returns the events still undelivered.
+func _syncWebhooks(queue []QueuedWebhook) []QueuedWebhook {
+	var remaining []QueuedWebhook
+
+	for _, item := range queue {
+		var err error
+		delay := time.Second
+
+		for attempt := 0; attempt < syncRetryAttempts; attempt++ {
+			if attempt > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+
+			item.Attempts++
+			item.LastAttempt = time.Now()
+
+			if err = _deliverWebhook(item.Event); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			_printUpstreamError(err)
+			remaining = append(remaining, item)
+		} else {
+			fmt.Println(fmt.Sprintf("Delivered %s event for %s.", item.Event.Event, item.Event.Task))
+		}
+	}
+
+	return remaining
+}